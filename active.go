@@ -0,0 +1,71 @@
+package tracer
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxActiveSpans bounds the spans SetTrackActive keeps; spans started
+// while the registry is full are not tracked.
+const maxActiveSpans = 10000
+
+// ActiveSpanInfo describes a span that has started but not ended.
+// Attributes holds the attributes given at start, redacted as for export;
+// attributes set later are not included.
+type ActiveSpanInfo struct {
+	Name       string
+	TraceID    trace.TraceID
+	SpanID     trace.SpanID
+	Start      time.Time
+	Attributes map[string]any
+}
+
+var activeSpans = struct {
+	sync.Mutex
+	m map[*Span]ActiveSpanInfo
+}{m: map[*Span]ActiveSpanInfo{}}
+
+// SetTrackActive makes New and End maintain a registry of running spans,
+// for debug endpoints, read with ActiveSpans. At most maxActiveSpans are
+// kept.
+func SetTrackActive(enabled bool) {
+	cfg.trackActive = enabled
+}
+
+// ActiveSpans returns the tracked running spans, oldest first.
+func ActiveSpans() []ActiveSpanInfo {
+	activeSpans.Lock()
+	out := make([]ActiveSpanInfo, 0, len(activeSpans.m))
+	for _, info := range activeSpans.m {
+		out = append(out, info)
+	}
+	activeSpans.Unlock()
+
+	slices.SortFunc(out, func(a, b ActiveSpanInfo) int {
+		return a.Start.Compare(b.Start)
+	})
+	return out
+}
+
+func trackActive(s *Span, attrs *spanAttributes) {
+	sc := s.Span.SpanContext()
+	info := ActiveSpanInfo{Name: s.name, TraceID: sc.TraceID(), SpanID: sc.SpanID(), Start: s.start}
+	if attrs != nil {
+		info.Attributes = attrs.ToMap()
+	}
+
+	activeSpans.Lock()
+	defer activeSpans.Unlock()
+	if len(activeSpans.m) < maxActiveSpans {
+		activeSpans.m[s] = info
+	}
+}
+
+func untrackActive(s *Span) {
+	activeSpans.Lock()
+	delete(activeSpans.m, s)
+	activeSpans.Unlock()
+}
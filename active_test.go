@@ -0,0 +1,39 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestActiveSpans(t *testing.T) {
+	setup(t)
+	SetTrackActive(true)
+
+	held := NewWithAttrs(context.Background(), "held", NewAttrs().StrKV("tenant", "acme"))
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				s := New(context.Background(), "short")
+				_ = ActiveSpans()
+				s.End()
+			}
+		}()
+	}
+	wg.Wait()
+
+	active := ActiveSpans()
+	if len(active) != 1 || active[0].Name != "held" {
+		t.Fatalf("active spans = %v, want only held", active)
+	}
+	if active[0].SpanID != held.Span.SpanContext().SpanID() || active[0].Attributes["tenant"] != "acme" {
+		t.Errorf("active span info = %+v, want held's ID and tenant=acme", active[0])
+	}
+	held.End()
+	if n := len(ActiveSpans()); n != 0 {
+		t.Errorf("%d spans still active after End", n)
+	}
+}
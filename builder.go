@@ -0,0 +1,52 @@
+package tracer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanBuilder is a chained alternative to passing options to New.
+type SpanBuilder struct {
+	ctx  context.Context
+	name string
+	opt  startOptions
+	opts []Option
+}
+
+// Builder starts building a span named name under ctx, e.g.
+// Builder(ctx, "op").Kind("server").TracerName("svc").Start().
+func Builder(ctx context.Context, name string) *SpanBuilder {
+	return &SpanBuilder{ctx: ctx, name: name}
+}
+
+func (b *SpanBuilder) Kind(kind string) *SpanBuilder {
+	b.opts = append(b.opts, WithKind(kind))
+	return b
+}
+
+func (b *SpanBuilder) TracerName(name string) *SpanBuilder {
+	b.opts = append(b.opts, WithTracerName(name))
+	return b
+}
+
+// Attrs sets attributes visible to the sampler at start, as NewWithAttrs.
+func (b *SpanBuilder) Attrs(attrs *spanAttributes) *SpanBuilder {
+	b.opt.attrs = attrs
+	return b
+}
+
+func (b *SpanBuilder) Links(links ...trace.Link) *SpanBuilder {
+	b.opt.links = append(b.opt.links, links...)
+	return b
+}
+
+// Options applies any other span Option.
+func (b *SpanBuilder) Options(opts ...Option) *SpanBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+func (b *SpanBuilder) Start() *Span {
+	return start(b.ctx, b.name, newStartOptions(b.opt, b.opts))
+}
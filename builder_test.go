@@ -0,0 +1,38 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBuilder(t *testing.T) {
+	rec := setup(t)
+
+	cause := New(context.Background(), "cause")
+	cause.End()
+	s := Builder(context.Background(), "handle").
+		Kind("server").
+		TracerName("svc").
+		Attrs(NewAttrs().StrKV("tenant", "acme")).
+		Links(trace.LinkFromContext(cause.Ctx)).
+		Options(WithWorker("w-1")).
+		Start()
+	s.End()
+
+	span := spanNamed(t, rec, "handle")
+	if span.SpanKind != trace.SpanKindServer {
+		t.Errorf("kind = %v, want server", span.SpanKind)
+	}
+	if span.InstrumentationScope.Name != "svc" {
+		t.Errorf("scope = %q, want svc", span.InstrumentationScope.Name)
+	}
+	got := attrMap(span.Attributes)
+	if got["tenant"].AsString() != "acme" || got["worker.id"].AsString() != "w-1" {
+		t.Errorf("attributes = %v", span.Attributes)
+	}
+	if len(span.Links) != 1 || span.Links[0].SpanContext.SpanID() != cause.SpanIDRaw() {
+		t.Errorf("links = %v, want one to cause", span.Links)
+	}
+}
@@ -0,0 +1,364 @@
+package tracer
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// config holds package-level settings. Setters are meant to be called
+// during initialization, before spans are created.
+type config struct {
+	eventNameNormalizer func(string) string
+	eventRates          map[string]*eventRate
+	skipEmptyAttrs      bool
+	skipZeroNumerics    bool
+	redactor            Redactor
+	logRedactor         Redactor
+	valuePatterns       []valuePattern
+	clock               func() time.Time
+	autoEndEvent        bool
+	autoOK              bool
+	errorClassifier     func(error) string
+	dedupSliceAttrs     bool
+	panicFunc           func(*Span, any)
+	repanic             bool
+	attrValueByteBudget int
+	attrTrimmer         func(*Span, *spanAttributes)
+	outcomeHook         func(Outcome)
+	costHook            func(int)
+	errorRecordLimit    int
+	errorStackDepth     int
+	attrParser          AttributeParser
+	attrTransforms      []func([]attribute.KeyValue) []attribute.KeyValue
+	nonFiniteFloats     NonFiniteFloatPolicy
+	warnFunc            func(string)
+	defaultTracerName   string
+	defaultComponent    string
+	strictTracerName    bool
+	rateLimitedStatus   codes.Code
+	extractSorted       bool
+	maxSpanNameLen      int
+	maxStatusDescLen    int
+	maxLinks            int
+	retryableCategories map[string]struct{}
+	keepOnError         bool
+	promotedKeys        map[attribute.Key]struct{}
+	hashSalt            string
+	reuseParentSampling bool
+	debug               bool
+	semConvLint         bool
+	trackActive         bool
+	selfMetrics         *selfMetrics
+}
+
+var cfg = config{
+	eventNameNormalizer: identity,
+	clock:               time.Now,
+	errorClassifier:     defaultErrorClassifier,
+	attrParser:          defaultParser{},
+	warnFunc:            defaultWarn,
+	retryableCategories: map[string]struct{}{"timeout": {}},
+}
+
+func identity(name string) string { return name }
+
+func now() time.Time {
+	return cfg.clock()
+}
+
+// SetClock replaces the clock used for span timestamps and durations,
+// mainly for tests. A nil clock restores time.Now.
+func SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	cfg.clock = clock
+}
+
+// SetAutoEndEvent makes End add a "span.end" event carrying the span
+// duration as duration_ms.
+func SetAutoEndEvent(enabled bool) {
+	cfg.autoEndEvent = enabled
+}
+
+// SetAutoOK makes End set Ok status on spans whose status is still Unset
+// and that recorded no error, soft errors included, and did not panic.
+// Spans started with WithNoRecover are left Unset.
+func SetAutoOK(enabled bool) {
+	cfg.autoOK = enabled
+}
+
+// SetEventNameNormalizer sets the function applied to every event name
+// before it is added to a span. A nil fn restores the identity default.
+func SetEventNameNormalizer(fn func(string) string) {
+	if fn == nil {
+		fn = identity
+	}
+	cfg.eventNameNormalizer = fn
+}
+
+// SetSkipEmptyAttrs makes Parse omit empty string values and empty string
+// slices. Bool values are never skipped.
+func SetSkipEmptyAttrs(skip bool) {
+	cfg.skipEmptyAttrs = skip
+}
+
+// SetSkipZeroNumericAttrs makes Parse omit int and float values equal to
+// zero. It is separate from SetSkipEmptyAttrs because zero is often a
+// legitimate value.
+func SetSkipZeroNumericAttrs(skip bool) {
+	cfg.skipZeroNumerics = skip
+}
+
+// SetDedupSliceAttrs makes Parse drop repeated elements of string slice
+// attributes, keeping the first occurrence of each.
+func SetDedupSliceAttrs(enabled bool) {
+	cfg.dedupSliceAttrs = enabled
+}
+
+// SetAttrValueByteBudget caps the total bytes of string attribute values
+// emitted by one Parse call. Once over budget, the longest values are
+// dropped first (ties broken by key) until the rest fit, and
+// attrs.truncated=true is added. Slices and other types are not counted.
+// A budget <= 0 disables the cap.
+func SetAttrValueByteBudget(n int) {
+	cfg.attrValueByteBudget = n
+}
+
+// SetAttrTrimmer sets a hook Extract runs on the span's Attrs before they
+// are applied, e.g. to drop verbose attributes when
+// s.Span.SpanContext().IsSampled() is false.
+func SetAttrTrimmer(fn func(*Span, *spanAttributes)) {
+	cfg.attrTrimmer = fn
+}
+
+// AttributeParser converts an attribute set into otel key-values. The
+// default calls spanAttributes.Parse.
+type AttributeParser interface {
+	Parse(*spanAttributes) []attribute.KeyValue
+}
+
+type defaultParser struct{}
+
+func (defaultParser) Parse(a *spanAttributes) []attribute.KeyValue {
+	return a.Parse()
+}
+
+// SetAttributeParser sets the parser used by Extract and by event
+// attributes. A nil p restores the default.
+func SetAttributeParser(p AttributeParser) {
+	if p == nil {
+		p = defaultParser{}
+	}
+	cfg.attrParser = p
+}
+
+// AddAttrTransform adds fn to the transforms applied, in the order added,
+// to parsed attributes just before they reach the otel span: start-time
+// attributes, those applied by Extract, and event and link attributes.
+// It suits renaming legacy keys or adding prefixes. Parse and ToMap
+// results are not transformed.
+func AddAttrTransform(fn func(kvs []attribute.KeyValue) []attribute.KeyValue) {
+	cfg.attrTransforms = append(cfg.attrTransforms, fn)
+}
+
+func transformAttrs(kvs []attribute.KeyValue) []attribute.KeyValue {
+	for _, fn := range cfg.attrTransforms {
+		kvs = fn(kvs)
+	}
+	return kvs
+}
+
+// NonFiniteFloatPolicy controls how Parse handles NaN and infinite floats,
+// which some backends reject.
+type NonFiniteFloatPolicy int
+
+const (
+	// NonFiniteDrop omits the value and records <key>.invalid=true.
+	NonFiniteDrop NonFiniteFloatPolicy = iota
+	// NonFiniteString records the value as a string: "NaN", "+Inf" or "-Inf".
+	NonFiniteString
+	// NonFiniteKeep passes the value through unchanged.
+	NonFiniteKeep
+)
+
+func (p NonFiniteFloatPolicy) String() string {
+	switch p {
+	case NonFiniteDrop:
+		return "drop"
+	case NonFiniteString:
+		return "string"
+	case NonFiniteKeep:
+		return "keep"
+	}
+	return fmt.Sprintf("NonFiniteFloatPolicy(%d)", int(p))
+}
+
+func SetNonFiniteFloatPolicy(policy NonFiniteFloatPolicy) {
+	cfg.nonFiniteFloats = policy
+}
+
+func defaultWarn(msg string) {
+	log.Print("tracer: " + msg)
+}
+
+func warn(format string, args ...any) {
+	cfg.warnFunc(fmt.Sprintf(format, args...))
+}
+
+// SetWarnFunc sets where the package reports misuse it detects at
+// runtime. The default logs with the standard logger; a nil fn restores it.
+func SetWarnFunc(fn func(msg string)) {
+	if fn == nil {
+		fn = defaultWarn
+	}
+	cfg.warnFunc = fn
+}
+
+// SetDefaultTracerName sets the tracer name used when none is given.
+func SetDefaultTracerName(name string) {
+	cfg.defaultTracerName = name
+}
+
+// SetDefaultComponent sets the component attribute recorded on spans
+// started without WithComponent. Empty records none.
+func SetDefaultComponent(name string) {
+	cfg.defaultComponent = name
+}
+
+// SetStrictTracerName makes New warn and return a no-op span when the
+// tracer name is empty and no default is set, instead of creating spans
+// under the empty instrumentation scope.
+func SetStrictTracerName(enabled bool) {
+	cfg.strictTracerName = enabled
+}
+
+// ConfigSnapshot returns the current package settings for logging at
+// startup. Hooks are reported as whether a custom one is set; their
+// contents, such as redaction rules, are not included.
+func ConfigSnapshot() map[string]any {
+	return map[string]any{
+		"event_name_normalizer":   !sameFunc(cfg.eventNameNormalizer, identity),
+		"event_sample_rates":      eventSampleRates(),
+		"skip_empty_attrs":        cfg.skipEmptyAttrs,
+		"skip_zero_numeric_attrs": cfg.skipZeroNumerics,
+		"redactor":                cfg.redactor != nil,
+		"log_redactor":            cfg.logRedactor != nil,
+		"value_redact_patterns":   len(cfg.valuePatterns),
+		"clock":                   !sameFunc(cfg.clock, time.Now),
+		"auto_end_event":          cfg.autoEndEvent,
+		"auto_ok":                 cfg.autoOK,
+		"error_classifier":        !sameFunc(cfg.errorClassifier, defaultErrorClassifier),
+		"dedup_slice_attrs":       cfg.dedupSliceAttrs,
+		"panic_func":              cfg.panicFunc != nil,
+		"repanic":                 cfg.repanic,
+		"attr_value_byte_budget":  cfg.attrValueByteBudget,
+		"attr_trimmer":            cfg.attrTrimmer != nil,
+		"outcome_hook":            cfg.outcomeHook != nil,
+		"cost_hook":               cfg.costHook != nil,
+		"error_record_limit":      cfg.errorRecordLimit,
+		"error_stack_depth":       cfg.errorStackDepth,
+		"attr_parser":             fmt.Sprintf("%T", cfg.attrParser),
+		"attr_transforms":         len(cfg.attrTransforms),
+		"non_finite_float_policy": cfg.nonFiniteFloats.String(),
+		"warn_func":               !sameFunc(cfg.warnFunc, defaultWarn),
+		"default_tracer_name":     cfg.defaultTracerName,
+		"default_component":       cfg.defaultComponent,
+		"strict_tracer_name":      cfg.strictTracerName,
+		"rate_limited_status":     cfg.rateLimitedStatus.String(),
+		"extract_sorted":          cfg.extractSorted,
+		"max_span_name_len":       cfg.maxSpanNameLen,
+		"max_status_desc_len":     cfg.maxStatusDescLen,
+		"max_links_per_span":      cfg.maxLinks,
+		"retryable_categories":    slices.Sorted(maps.Keys(cfg.retryableCategories)),
+		"keep_on_error":           cfg.keepOnError,
+		"promoted_keys":           slices.Sorted(maps.Keys(cfg.promotedKeys)),
+		"hash_salt":               cfg.hashSalt != "",
+		"reuse_parent_sampling":   cfg.reuseParentSampling,
+		"debug":                   cfg.debug,
+		"semconv_lint":            cfg.semConvLint,
+		"track_active":            cfg.trackActive,
+		"self_metrics":            cfg.selfMetrics != nil,
+	}
+}
+
+func eventSampleRates() map[string]float64 {
+	rates := make(map[string]float64, len(cfg.eventRates))
+	for name, r := range cfg.eventRates {
+		rates[name] = r.rate
+	}
+	return rates
+}
+
+func sameFunc(a, b any) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// SetRateLimitedStatus sets the status Span.RateLimited applies. The
+// default, codes.Unset, leaves the status untouched so a rate limit is not
+// reported as an error.
+func SetRateLimitedStatus(code codes.Code) {
+	cfg.rateLimitedStatus = code
+}
+
+// SetExtractSorted makes Extract apply attributes in key order. The otel
+// SDK does not promise to keep that order, but exporters built on SDKs
+// that do get a canonical layout.
+func SetExtractSorted(enabled bool) {
+	cfg.extractSorted = enabled
+}
+
+// SetMaxSpanNameLen truncates span names longer than n bytes, marking the
+// cut with "...". A value <= 0 leaves names unlimited.
+func SetMaxSpanNameLen(n int) {
+	cfg.maxSpanNameLen = n
+}
+
+// SetMaxStatusDescLen truncates status descriptions, usually error
+// messages, longer than n bytes, marking the cut with "...". A value <= 0
+// leaves them unlimited.
+func SetMaxStatusDescLen(n int) {
+	cfg.maxStatusDescLen = n
+}
+
+// SetMaxLinksPerSpan caps the links each span keeps, whether given at
+// start or added later. Links over the cap are dropped and counted in
+// dropped_links. A value <= 0 leaves links unlimited.
+func SetMaxLinksPerSpan(n int) {
+	cfg.maxLinks = n
+}
+
+// SetHashSalt sets the salt UserIDKV prepends before hashing. Keep it
+// secret and stable: changing it breaks correlation with earlier spans.
+func SetHashSalt(salt string) {
+	cfg.hashSalt = salt
+}
+
+// SetReuseParentSampling makes Child reuse the decision cached on a parent
+// that was neither sampled nor recording: the child is created without
+// going through the tracer, with the parent's trace ID and flags and a
+// random span ID of its own. Span processors never see such spans anyway,
+// but the provider's ID generator is not used for them. Skipping the
+// tracer and its start options makes such children cheaper; see
+// BenchmarkChildren1000. It is an optimization, not a semantic change,
+// for parent-based samplers such as the SDK default and
+// RecommendedSampler, which would drop such children anyway. Do not
+// enable it with samplers that may sample a child of an unsampled parent.
+// Contexts marked with WithForceSample still go through the sampler.
+func SetReuseParentSampling(enabled bool) {
+	cfg.reuseParentSampling = enabled
+}
+
+// SetDebug enables extra misuse checks reported through the warn func,
+// such as setting attributes on a span after End. They cost a little on
+// hot paths, so leave it off in production.
+func SetDebug(enabled bool) {
+	cfg.debug = enabled
+}
@@ -0,0 +1,69 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestConfigSnapshot(t *testing.T) {
+	setup(t)
+	before := ConfigSnapshot()
+	if before["redactor"] != false || before["error_record_limit"] != 0 {
+		t.Fatalf("defaults = %v", before)
+	}
+
+	SetRedactor(RedactKeys("password"))
+	SetErrorRecordLimit(5)
+	SetMaxSpanNameLen(64)
+	got := ConfigSnapshot()
+
+	if got["redactor"] != true {
+		t.Error("redactor not reported as set")
+	}
+	if got["error_record_limit"] != 5 {
+		t.Errorf("error_record_limit = %v, want 5", got["error_record_limit"])
+	}
+	if got["max_span_name_len"] != 64 {
+		t.Errorf("max_span_name_len = %v, want 64", got["max_span_name_len"])
+	}
+}
+
+func TestAddAttrTransform(t *testing.T) {
+	rec := setup(t)
+	AddAttrTransform(func(kvs []attribute.KeyValue) []attribute.KeyValue {
+		for i, kv := range kvs {
+			if kv.Key == "legacy_user" {
+				kvs[i].Key = "user.id"
+			}
+		}
+		return kvs
+	})
+	AddAttrTransform(func(kvs []attribute.KeyValue) []attribute.KeyValue {
+		for i, kv := range kvs {
+			kvs[i].Key = "app." + kv.Key
+		}
+		return kvs
+	})
+
+	other := New(context.Background(), "other")
+	other.End()
+	s := New(context.Background(), "op")
+	s.Attrs.StrKV("legacy_user", "u1").StrKV("tenant", "acme")
+	s.Event("step").Str("n", "1").Add()
+	s.AddLink(other.Ctx, *NewAttrs().StrKV("reason", "batch"))
+	s.End()
+
+	span := spanNamed(t, rec, "op")
+	got := attrMap(span.Attributes)
+	if got["app.user.id"].AsString() != "u1" || got["app.tenant"].AsString() != "acme" {
+		t.Errorf("attributes = %v, want app.user.id and app.tenant", span.Attributes)
+	}
+	if _, ok := attrMap(span.Events[0].Attributes)["app.n"]; !ok {
+		t.Errorf("event attributes = %v, want app.n", span.Events[0].Attributes)
+	}
+	if _, ok := attrMap(span.Links[0].Attributes)["app.reason"]; !ok {
+		t.Errorf("link attributes = %v, want app.reason", span.Links[0].Attributes)
+	}
+}
@@ -0,0 +1,80 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanCtxKey struct{}
+
+// StoreInContext returns a copy of ctx carrying s, so the full wrapper
+// (including Attrs) can be retrieved downstream with LoadFromContext.
+func StoreInContext(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, s)
+}
+
+// LoadFromContext returns the Span stored by StoreInContext.
+func LoadFromContext(ctx context.Context) (*Span, bool) {
+	s, ok := ctx.Value(spanCtxKey{}).(*Span)
+	return s, ok && s != nil
+}
+
+// NewSpanContext builds a remote span context from hex-encoded IDs.
+func NewSpanContext(traceID, spanID string, sampled bool) (trace.SpanContext, error) {
+	if len(traceID) != 32 {
+		return trace.SpanContext{}, fmt.Errorf("invalid trace id length %d, want 32", len(traceID))
+	}
+	if len(spanID) != 16 {
+		return trace.SpanContext{}, fmt.Errorf("invalid span id length %d, want 16", len(spanID))
+	}
+
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("invalid trace id %q: %w", traceID, err)
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("invalid span id %q: %w", spanID, err)
+	}
+
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: flags,
+		Remote:     true,
+	}), nil
+}
+
+// MustFromContext returns the Span stored in ctx, or else a wrapper around
+// the otel span in ctx, which is a no-op span when ctx has none. The
+// result is always safe to call methods on. The wrapper borrows the otel
+// span, which was started by other instrumentation: End and its variants
+// apply Attrs and any panic or error to it but never end it, and leave
+// out what depends on the span's start time, such as SLO checks, the
+// outcome hook and auto OK.
+func MustFromContext(ctx context.Context) *Span {
+	if s, ok := LoadFromContext(ctx); ok {
+		return s
+	}
+	span := trace.SpanFromContext(ctx)
+	s := &Span{
+		Ctx:        ctx,
+		Span:       span,
+		tracerName: cfg.defaultTracerName,
+		start:      now(),
+		sampled:    span.SpanContext().IsSampled(),
+		borrowed:   true,
+		done:       make(chan struct{}),
+	}
+	if !span.IsRecording() {
+		s.discardEvents = &spanEvents{owner: s, discard: true}
+	}
+	return s
+}
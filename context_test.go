@@ -0,0 +1,132 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestStoreInContext(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	ctx := StoreInContext(context.Background(), s)
+
+	func(ctx context.Context) {
+		got, ok := LoadFromContext(ctx)
+		if !ok || got != s {
+			t.Fatalf("LoadFromContext = %p, %v, want %p", got, ok, s)
+		}
+		got.Attrs.StrKV("downstream", "yes")
+	}(ctx)
+	s.End()
+
+	if v := attrMap(onlySpan(t, rec).Attributes)["downstream"].AsString(); v != "yes" {
+		t.Errorf("downstream = %q, want yes", v)
+	}
+	if _, ok := LoadFromContext(context.Background()); ok {
+		t.Error("LoadFromContext found a span in an empty context")
+	}
+}
+
+func TestNewSpanContext(t *testing.T) {
+	const traceID, spanID = "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"
+
+	sc, err := NewSpanContext(traceID, spanID, true)
+	if err != nil {
+		t.Fatalf("NewSpanContext: %v", err)
+	}
+	if sc.TraceID().String() != traceID || sc.SpanID().String() != spanID {
+		t.Errorf("IDs = %s/%s, want %s/%s", sc.TraceID(), sc.SpanID(), traceID, spanID)
+	}
+	if !sc.IsSampled() || !sc.IsRemote() {
+		t.Errorf("sampled = %v, remote = %v, want both true", sc.IsSampled(), sc.IsRemote())
+	}
+
+	for _, tc := range []struct{ traceID, spanID string }{
+		{traceID[:30], spanID},
+		{traceID, spanID + "00"},
+		{"zz" + traceID[2:], spanID},
+	} {
+		if _, err := NewSpanContext(tc.traceID, tc.spanID, false); err == nil {
+			t.Errorf("NewSpanContext(%q, %q) succeeded, want an error", tc.traceID, tc.spanID)
+		}
+	}
+}
+
+func TestMustFromContext(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	if got := MustFromContext(s.Ctx); got != s {
+		t.Errorf("MustFromContext = %p, want the stored span %p", got, s)
+	}
+	s.End()
+
+	empty := MustFromContext(context.Background())
+	empty.Attrs.StrKV("k", "v")
+	empty.Event("e").Str("k", "v").Add()
+	empty.Error(errors.New("ignored"))
+	empty.CacheEvent("k", true)
+	if child := empty.Child("child"); child == nil {
+		t.Error("Child of the wrapper is nil")
+	} else {
+		child.End()
+	}
+	empty.End()
+	empty.End()
+
+	if empty.Span.IsRecording() {
+		t.Error("wrapper for an empty context is recording")
+	}
+	if n := len(rec.Spans()); n != 2 {
+		t.Errorf("got %d spans, want op and the wrapper's child", n)
+	}
+}
+
+func TestMustFromContextBorrowsForeignSpan(t *testing.T) {
+	rec := setup(t)
+	SetAutoOK(true)
+
+	ctx, foreign := rec.Provider().Tracer("otelhttp").Start(context.Background(), "foreign")
+	s := MustFromContext(ctx)
+	s.Attrs.StrKV("tenant", "acme")
+	s.End()
+	if !foreign.IsRecording() || len(rec.Spans()) != 0 {
+		t.Fatal("End on the wrapper ended the foreign span")
+	}
+	foreign.End()
+
+	span := onlySpan(t, rec)
+	if v := attrMap(span.Attributes)["tenant"].AsString(); v != "acme" {
+		t.Errorf("tenant = %q, want the wrapper's attributes on the foreign span", v)
+	}
+	if span.Status.Code != codes.Unset {
+		t.Errorf("status = %v, want Unset as the wrapper does not own the span", span.Status.Code)
+	}
+}
+
+func addNoopEvent(s *Span) {
+	s.Event("work").Str("k", "v").Int("n", 1).Bool("ok", true).Add()
+}
+
+func TestNoopSpanEventsDoNotAllocate(t *testing.T) {
+	setup(t)
+	s := MustFromContext(context.Background())
+
+	if n := testing.AllocsPerRun(100, func() { addNoopEvent(s) }); n != 0 {
+		t.Errorf("event on a no-op span allocated %v times, want 0", n)
+	}
+}
+
+func BenchmarkNoopSpanEvent(b *testing.B) {
+	setup(b)
+	s := MustFromContext(context.Background())
+	b.ReportAllocs()
+
+	for b.Loop() {
+		addNoopEvent(s)
+	}
+}
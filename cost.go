@@ -0,0 +1,37 @@
+package tracer
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetCostHook sets a callback End invokes with a rough estimate, in bytes,
+// of the span's exported size: its name, the key and value lengths of its
+// attributes and, for each event, its name and attributes. It is meant for
+// tracking telemetry spend, not for exact accounting.
+func SetCostHook(fn func(bytes int)) {
+	cfg.costHook = fn
+}
+
+func kvBytes(kvs []attribute.KeyValue) int {
+	n := 0
+	for _, kv := range kvs {
+		n += len(kv.Key) + len(kv.Value.Emit())
+	}
+	return n
+}
+
+func (s *Span) countEventCost(name string, opts []trace.EventOption) {
+	if cfg.costHook == nil {
+		return
+	}
+	c := trace.NewEventConfig(opts...)
+	s.eventCost.Add(int64(len(name) + kvBytes(c.Attributes())))
+}
+
+func (s *Span) reportCost() {
+	if cfg.costHook == nil {
+		return
+	}
+	cfg.costHook(len(s.name) + s.attrCost + int(s.eventCost.Load()))
+}
@@ -0,0 +1,32 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCostHook(t *testing.T) {
+	setup(t)
+	var costs []int
+	SetCostHook(func(bytes int) { costs = append(costs, bytes) })
+
+	small := New(context.Background(), "op")
+	small.Attrs.StrKV("k", "v")
+	small.End()
+
+	large := New(context.Background(), "op")
+	large.Attrs.StrKV("k", strings.Repeat("v", 1000))
+	large.Event("cached").Add()
+	large.End()
+
+	if len(costs) != 2 {
+		t.Fatalf("hook called %d times, want once per span", len(costs))
+	}
+	if costs[0] < len("op")+len("kv") {
+		t.Errorf("small span cost = %d, want at least its name and attribute", costs[0])
+	}
+	if diff := costs[1] - costs[0]; diff < 999+len("cached") {
+		t.Errorf("large span cost is %d more than small, want at least the added value and event", diff)
+	}
+}
@@ -0,0 +1,153 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetErrorClassifier sets the function Span.Error uses to bucket errors
+// into an error.category attribute such as timeout, validation, not_found
+// or internal. An empty category adds no attribute. A nil fn restores the
+// default, which only recognizes context.DeadlineExceeded as timeout.
+func SetErrorClassifier(fn func(error) string) {
+	if fn == nil {
+		fn = defaultErrorClassifier
+	}
+	cfg.errorClassifier = fn
+}
+
+// SetErrorRecordLimit caps how many errors each span records as exception
+// events; status updates are unaffected. Errors over the limit are counted
+// in recorded_errors_dropped. Panics are always recorded. A limit <= 0
+// records every error.
+func SetErrorRecordLimit(n int) {
+	cfg.errorRecordLimit = n
+}
+
+func (s *Span) recordError(err error, opts ...trace.EventOption) {
+	if cfg.errorRecordLimit > 0 && s.recordedErrs >= cfg.errorRecordLimit {
+		s.droppedErrs++
+		return
+	}
+
+	s.recordedErrs++
+	s.Span.RecordError(err, opts...)
+}
+
+// SetRetryableCategories sets the error categories Span.Retryable treats
+// as retryable. The default is timeout.
+func SetRetryableCategories(categories ...string) {
+	set := make(map[string]struct{}, len(categories))
+	for _, c := range categories {
+		set[c] = struct{}{}
+	}
+	cfg.retryableCategories = set
+}
+
+// Retryable reports whether the category of the last error passed to
+// Error is retryable. See SetErrorClassifier and SetRetryableCategories.
+func (s *Span) Retryable() bool {
+	if s.errorCategory == "" {
+		return false
+	}
+	_, ok := cfg.retryableCategories[s.errorCategory]
+	return ok
+}
+
+// SetKeepOnError makes Error and SError on a span that was not sampled
+// mark its Ctx with WithForceSample, so spans started from it afterwards,
+// locally or downstream through RecommendedSampler, are kept and the
+// failing part of the trace is not lost.
+func SetKeepOnError(enabled bool) {
+	cfg.keepOnError = enabled
+}
+
+func (s *Span) keepOnError() {
+	if !cfg.keepOnError || s.sampled || s.Ctx == nil || forceSampled(s.Ctx) {
+		return
+	}
+	s.Ctx = WithForceSample(s.Ctx)
+}
+
+func defaultErrorClassifier(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return ""
+}
+
+func (s *Span) classify(err error) {
+	category := cfg.errorClassifier(err)
+	s.errorCategory = category
+	if category == "" {
+		return
+	}
+
+	s.Attrs.StrKV("error.category", category)
+}
+
+// ErrorBubble calls Error with err and also marks the parent Span, when
+// there is one in the context s was started from, as Error with
+// child_error=true. The parent must not have ended yet.
+func (s *Span) ErrorBubble(err error) {
+	if err == nil {
+		return
+	}
+
+	s.Error(err)
+	if s.parent == nil || s.parent.noop {
+		return
+	}
+	s.parent.Attrs.BoolKV("child_error", true)
+	s.parent.Error(err)
+}
+
+// ErrorCode is Error with err recorded as an exception event and the
+// application error code attached as error.code.
+func (s *Span) ErrorCode(code string, err error) {
+	if err == nil {
+		return
+	}
+
+	s.Attrs.StrKV("error.code", code)
+	s.Error(err, true)
+}
+
+// SoftError records a non-fatal error as an exception event without
+// touching the span status. See PromoteErrorsIfOver.
+func (s *Span) SoftError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.softErrors++
+	s.errorCount++
+	s.recordError(err)
+}
+
+// PromoteErrorsIfOver sets Error status when more than n soft errors were
+// recorded.
+func (s *Span) PromoteErrorsIfOver(n int) {
+	if s.softErrors <= n {
+		return
+	}
+
+	s.SError(fmt.Sprintf("%d errors exceeded threshold of %d", s.softErrors, n))
+}
+
+// RetriesExhausted marks the span as failed after retrying, recording
+// retry.exhausted, retry.attempts and the last error as retry.last_error.
+func (s *Span) RetriesExhausted(attempts int, lastErr error) {
+	s.Attrs.BoolKV("retry.exhausted", true).
+		IntKV("retry.attempts", attempts).
+		ErrorKV("retry.last_error", lastErr)
+
+	if lastErr == nil {
+		s.SError(fmt.Sprintf("retries exhausted after %d attempts", attempts))
+		return
+	}
+	s.Error(fmt.Errorf("retries exhausted after %d attempts: %w", attempts, lastErr), true)
+}
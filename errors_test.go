@@ -0,0 +1,225 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestErrorClassifier(t *testing.T) {
+	rec := setup(t)
+
+	timeout := New(context.Background(), "timeout")
+	timeout.Error(fmt.Errorf("fetch: %w", context.DeadlineExceeded))
+	timeout.End()
+
+	SetErrorClassifier(func(err error) string {
+		if errors.Is(err, errNotFound) {
+			return "not_found"
+		}
+		return "internal"
+	})
+	custom := New(context.Background(), "custom")
+	custom.Error(fmt.Errorf("user 7: %w", errNotFound))
+	custom.End()
+
+	if v := attrMap(spanNamed(t, rec, "timeout").Attributes)["error.category"].AsString(); v != "timeout" {
+		t.Errorf("default category = %q, want timeout", v)
+	}
+	if v := attrMap(spanNamed(t, rec, "custom").Attributes)["error.category"].AsString(); v != "not_found" {
+		t.Errorf("custom category = %q, want not_found", v)
+	}
+}
+
+func TestPromoteErrorsIfOver(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "batch")
+	for i := range 3 {
+		s.SoftError(fmt.Errorf("item %d failed", i))
+	}
+	s.PromoteErrorsIfOver(2)
+	s.End()
+
+	span := onlySpan(t, rec)
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+	if len(span.Events) != 3 {
+		t.Errorf("got %d exception events, want 3", len(span.Events))
+	}
+}
+
+func TestSoftErrorsUnderThreshold(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "batch")
+	s.SoftError(errNotFound)
+	s.SoftError(errNotFound)
+	s.PromoteErrorsIfOver(2)
+	s.End()
+
+	if code := onlySpan(t, rec).Status.Code; code == codes.Error {
+		t.Error("status = Error with soft errors at the threshold")
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "call")
+	s.RetriesExhausted(3, errNotFound)
+	s.End()
+
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	if !got["retry.exhausted"].AsBool() {
+		t.Error("retry.exhausted is not true")
+	}
+	if v := got["retry.attempts"].AsInt64(); v != 3 {
+		t.Errorf("retry.attempts = %d, want 3", v)
+	}
+	if v := got["retry.last_error"].AsString(); v != errNotFound.Error() {
+		t.Errorf("retry.last_error = %q, want %q", v, errNotFound)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+}
+
+func TestErrorRecordLimit(t *testing.T) {
+	rec := setup(t)
+	SetErrorRecordLimit(2)
+
+	s := New(context.Background(), "storm")
+	for range 5 {
+		s.Error(errNotFound, true)
+	}
+	s.End()
+
+	span := onlySpan(t, rec)
+	if len(span.Events) != 2 {
+		t.Errorf("got %d exception events, want 2", len(span.Events))
+	}
+	if v := attrMap(span.Attributes)["recorded_errors_dropped"].AsInt64(); v != 3 {
+		t.Errorf("recorded_errors_dropped = %d, want 3", v)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+}
+
+var errValidation = errors.New("invalid input")
+
+func TestRetryable(t *testing.T) {
+	setup(t)
+	SetErrorClassifier(func(err error) string {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return "timeout"
+		case errors.Is(err, errValidation):
+			return "validation"
+		}
+		return ""
+	})
+
+	timeout := New(context.Background(), "timeout")
+	defer timeout.End()
+	timeout.Error(context.DeadlineExceeded)
+	if !timeout.Retryable() {
+		t.Error("timeout error is not retryable")
+	}
+
+	invalid := New(context.Background(), "invalid")
+	defer invalid.End()
+	invalid.Error(errValidation)
+	if invalid.Retryable() {
+		t.Error("validation error is retryable")
+	}
+
+	SetRetryableCategories("validation")
+	if !invalid.Retryable() || timeout.Retryable() {
+		t.Error("SetRetryableCategories did not replace the retryable set")
+	}
+}
+
+func TestErrorBubble(t *testing.T) {
+	rec := setup(t)
+
+	parent := New(context.Background(), "parent")
+	child := parent.Child("child")
+	child.ErrorBubble(errNotFound)
+	child.End()
+	parent.End()
+
+	if code := spanNamed(t, rec, "child").Status.Code; code != codes.Error {
+		t.Errorf("child status = %v, want Error", code)
+	}
+	p := spanNamed(t, rec, "parent")
+	if p.Status.Code != codes.Error || !attrMap(p.Attributes)["child_error"].AsBool() {
+		t.Errorf("parent = %v %v, want Error with child_error", p.Status, p.Attributes)
+	}
+
+	root := New(context.Background(), "root")
+	root.ErrorBubble(errNotFound)
+	root.End()
+	if code := spanNamed(t, rec, "root").Status.Code; code != codes.Error {
+		t.Errorf("root status = %v, want Error", code)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.ErrorCode("E_QUOTA", errors.New("quota exceeded"))
+	s.End()
+
+	span := onlySpan(t, rec)
+	if span.Status.Code != codes.Error || span.Status.Description != "quota exceeded" {
+		t.Errorf("status = %v %q, want Error quota exceeded", span.Status.Code, span.Status.Description)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Errorf("events = %v, want the recorded error", span.Events)
+	}
+	if v := attrMap(span.Attributes)["error.code"].AsString(); v != "E_QUOTA" {
+		t.Errorf("error.code = %q, want E_QUOTA", v)
+	}
+}
+
+// keepFlagSampler records every span but samples none, noting for each
+// start whether the parent context carried the keep flag.
+type keepFlagSampler struct{ flagged []bool }
+
+func (k *keepFlagSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	k.flagged = append(k.flagged, forceSampled(p.ParentContext))
+	return sdktrace.SamplingResult{Decision: sdktrace.RecordOnly}
+}
+
+func (*keepFlagSampler) Description() string { return "keepFlagSampler" }
+
+func TestKeepOnError(t *testing.T) {
+	sampler := &keepFlagSampler{}
+	setup(t, sdktrace.WithSampler(sampler))
+	SetKeepOnError(true)
+
+	s := New(context.Background(), "op")
+	s.Child("before").End()
+	s.Error(errors.New("boom"))
+	s.Child("after").End()
+	s.End()
+
+	if !forceSampled(s.Ctx) {
+		t.Error("Error did not set the keep flag on the span's context")
+	}
+	if want := []bool{false, false, true}; !slices.Equal(sampler.flagged, want) {
+		t.Errorf("sampler saw keep flags %v, want %v", sampler.flagged, want)
+	}
+}
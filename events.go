@@ -0,0 +1,158 @@
+package tracer
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEvent records a cache lookup as a "cache" event and counts hits
+// and misses, added to the span as cache.hits and cache.misses on End.
+func (s *Span) CacheEvent(key string, hit bool) {
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+
+	s.Event("cache").Str("cache.key", key).Bool("cache.hit", hit).Add()
+}
+
+// DependencyEvent records a call to a dependency made without a child span
+// as a "dependency" event with its name, duration and, on failure, the
+// error message.
+func (s *Span) DependencyEvent(name string, d time.Duration, err error) {
+	e := s.Event("dependency").
+		Str("dependency.name", name).
+		Int("dependency.duration_ms", durationMs(d)).
+		Bool("dependency.error", err != nil)
+	if err != nil {
+		e.Str("error.message", err.Error())
+	}
+	e.Add()
+}
+
+// LockEvent records an attempt to take the lock name as a "lock" event
+// with lock.name, lock.wait_ms and lock.acquired.
+func (s *Span) LockEvent(name string, waited time.Duration, acquired bool) {
+	s.Event("lock").
+		Str("lock.name", name).
+		Int("lock.wait_ms", durationMs(waited)).
+		Bool("lock.acquired", acquired).
+		Add()
+}
+
+// EventSpec describes one event for AddEvents.
+type EventSpec struct {
+	Msg   string
+	Attrs *spanAttributes
+}
+
+// AddEvents adds events that all share the timestamp ts.
+func (s *Span) AddEvents(ts time.Time, events ...EventSpec) {
+	for _, spec := range events {
+		e := s.Event(spec.Msg).Timestamp(ts)
+		if spec.Attrs != nil {
+			e.Attributes(spec.Attrs)
+		}
+		e.Add()
+	}
+}
+
+// maxBufferedEvents bounds the events BufferEvent keeps per context.
+const maxBufferedEvents = 32
+
+type eventBufferKey struct{}
+
+type eventBuffer struct {
+	mu     sync.Mutex
+	events []EventSpec
+	times  []time.Time
+}
+
+// BufferEvent adds an event to the Span in ctx or, when ctx has no Span
+// that is still running, holds it until the next span is started from ctx
+// or a context derived from it. That span gets the buffered events, with
+// the time they were buffered, when it starts; later spans do not. The
+// returned context carries the buffer and must be passed on. At most
+// maxBufferedEvents are held; further events are dropped.
+func BufferEvent(ctx context.Context, msg string, attrs *spanAttributes) context.Context {
+	if s, ok := LoadFromContext(ctx); ok && !s.Ended() {
+		e := s.Event(msg)
+		if attrs != nil {
+			e.Attributes(attrs)
+		}
+		e.Add()
+		return ctx
+	}
+
+	buf, ok := ctx.Value(eventBufferKey{}).(*eventBuffer)
+	if !ok {
+		buf = &eventBuffer{}
+		ctx = context.WithValue(ctx, eventBufferKey{}, buf)
+	}
+	if attrs != nil {
+		attrs = attrs.clone()
+	}
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	if len(buf.events) < maxBufferedEvents {
+		buf.events = append(buf.events, EventSpec{Msg: msg, Attrs: attrs})
+		buf.times = append(buf.times, now())
+	}
+	return ctx
+}
+
+// flushBufferedEvents moves events buffered in ctx onto s.
+func (s *Span) flushBufferedEvents(ctx context.Context) {
+	buf, ok := ctx.Value(eventBufferKey{}).(*eventBuffer)
+	if !ok {
+		return
+	}
+
+	buf.mu.Lock()
+	events, times := buf.events, buf.times
+	buf.events, buf.times = nil, nil
+	buf.mu.Unlock()
+
+	for i, spec := range events {
+		s.AddEvents(times[i], spec)
+	}
+}
+
+type eventRate struct {
+	rate float64
+	seen atomic.Uint64
+}
+
+// SetEventSampleRate keeps only a fraction rate of the events named name
+// added through Event(...).Add, e.g. 0.1 for one in ten, to tame chatty
+// events such as polls. The choice is deterministic: across all spans,
+// the kept events are spread evenly over the sequence of additions.
+// Names without a rate are always kept; a rate >= 1 removes the limit.
+// Rates apply to the name after SetEventNameNormalizer, the one exported.
+func SetEventSampleRate(name string, rate float64) {
+	rates := maps.Clone(cfg.eventRates)
+	if rates == nil {
+		rates = map[string]*eventRate{}
+	}
+	if rate >= 1 {
+		delete(rates, name)
+	} else {
+		rates[name] = &eventRate{rate: max(rate, 0)}
+	}
+	cfg.eventRates = rates
+}
+
+func keepEvent(name string) bool {
+	r, ok := cfg.eventRates[name]
+	if !ok {
+		return true
+	}
+
+	n := float64(r.seen.Add(1) - 1)
+	return uint64((n+1)*r.rate) > uint64(n*r.rate)
+}
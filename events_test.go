@@ -0,0 +1,213 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestCacheEvent(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "lookup")
+	s.CacheEvent("user:1", true)
+	s.CacheEvent("user:2", false)
+	s.CacheEvent("user:1", true)
+	s.End()
+
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	if got["cache.hits"].AsInt64() != 2 || got["cache.misses"].AsInt64() != 1 {
+		t.Errorf("cache.hits = %v, cache.misses = %v, want 2 and 1", got["cache.hits"].Emit(), got["cache.misses"].Emit())
+	}
+	if len(span.Events) != 3 {
+		t.Fatalf("got %d events, want 3", len(span.Events))
+	}
+	miss := attrMap(span.Events[1].Attributes)
+	if span.Events[1].Name != "cache" || miss["cache.key"].AsString() != "user:2" || miss["cache.hit"].AsBool() {
+		t.Errorf("second event = %v, want a cache miss for user:2", span.Events[1])
+	}
+}
+
+func TestDependencyEvent(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "handler")
+	s.DependencyEvent("redis", 12*time.Millisecond, nil)
+	s.DependencyEvent("billing-api", 300*time.Millisecond, errors.New("503 from upstream"))
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	ok, failed := attrMap(events[0].Attributes), attrMap(events[1].Attributes)
+	if ok["dependency.name"].AsString() != "redis" || ok["dependency.duration_ms"].AsInt64() != 12 || ok["dependency.error"].AsBool() {
+		t.Errorf("successful call = %v", events[0].Attributes)
+	}
+	if _, has := ok["error.message"]; has {
+		t.Error("successful call has error.message")
+	}
+	if failed["dependency.name"].AsString() != "billing-api" || failed["dependency.duration_ms"].AsInt64() != 300 ||
+		!failed["dependency.error"].AsBool() || failed["error.message"].AsString() != "503 from upstream" {
+		t.Errorf("failed call = %v", events[1].Attributes)
+	}
+}
+
+func TestAddEvents(t *testing.T) {
+	rec := setup(t)
+	ts := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	s := New(context.Background(), "op")
+	s.AddEvents(ts,
+		EventSpec{Msg: "validated"},
+		EventSpec{Msg: "priced", Attrs: NewAttrs().IntKV("total", 42)},
+		EventSpec{Msg: "reserved"},
+	)
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	for i, name := range []string{"validated", "priced", "reserved"} {
+		if events[i].Name != name || !events[i].Time.Equal(ts) {
+			t.Errorf("event %d = %s at %v, want %s at %v", i, events[i].Name, events[i].Time, name, ts)
+		}
+	}
+	if v := attrMap(events[1].Attributes)["total"].AsInt64(); v != 42 {
+		t.Errorf("priced total = %d, want 42", v)
+	}
+}
+
+func TestBufferEvent(t *testing.T) {
+	rec := setup(t)
+
+	ctx := BufferEvent(context.Background(), "config.loaded", NewAttrs().StrKV("source", "env"))
+	ctx = BufferEvent(ctx, "flags.fetched", nil)
+	s := New(ctx, "init")
+	BufferEvent(s.Ctx, "direct", nil)
+	s.End()
+	New(ctx, "later").End()
+
+	var names []string
+	for _, e := range spanNamed(t, rec, "init").Events {
+		names = append(names, e.Name)
+	}
+	if want := []string{"config.loaded", "flags.fetched", "direct"}; !slices.Equal(names, want) {
+		t.Errorf("init events = %q, want %q", names, want)
+	}
+	if n := len(spanNamed(t, rec, "later").Events); n != 0 {
+		t.Errorf("later span got %d events, want the buffer flushed once", n)
+	}
+}
+
+// countingParser is an AttributeParser that counts its calls.
+type countingParser struct{ n *int }
+
+func (p countingParser) Parse(a *spanAttributes) []attribute.KeyValue {
+	*p.n++
+	return a.Parse()
+}
+
+func TestEventOnNonRecordingSpan(t *testing.T) {
+	setup(t, sdktrace.WithSampler(sdktrace.NeverSample()))
+	var parsed int
+	SetAttributeParser(countingParser{&parsed})
+
+	s := New(context.Background(), "op")
+	s.Event("work").Str("k", "v").Int("n", 1).Add()
+	if parsed != 0 {
+		t.Errorf("event attributes parsed %d times on a non-recording span, want 0", parsed)
+	}
+	if n := s.eventCount.Load(); n != 1 {
+		t.Errorf("event count = %d, want the skipped event counted", n)
+	}
+	s.End()
+}
+
+func BenchmarkEventNonRecording(b *testing.B) {
+	setup(b, sdktrace.WithSampler(sdktrace.NeverSample()))
+	s := New(context.Background(), "op")
+	defer s.End()
+
+	for b.Loop() {
+		s.Event("work").Str("k", "v").Int("n", 1).Add()
+	}
+}
+
+func TestLockEvent(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.LockEvent("orders", 40*time.Millisecond, true)
+	s.LockEvent("inventory", time.Second, false)
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for i, want := range []struct {
+		name     string
+		waitMs   int64
+		acquired bool
+	}{{"orders", 40, true}, {"inventory", 1000, false}} {
+		got := attrMap(events[i].Attributes)
+		if events[i].Name != "lock" || got["lock.name"].AsString() != want.name ||
+			got["lock.wait_ms"].AsInt64() != want.waitMs || got["lock.acquired"].AsBool() != want.acquired {
+			t.Errorf("event %d = %s %v, want lock %+v", i, events[i].Name, got, want)
+		}
+	}
+}
+
+func TestEventSampleRate(t *testing.T) {
+	rec := setup(t)
+	SetEventSampleRate("poll", 0.1)
+
+	s := New(context.Background(), "worker")
+	for range 1000 {
+		s.Event("poll").Add()
+	}
+	for range 10 {
+		s.Event("job").Add()
+	}
+	s.End()
+
+	counts := map[string]int{}
+	for _, e := range onlySpan(t, rec).Events {
+		counts[e.Name]++
+	}
+	if counts["poll"] < 90 || counts["poll"] > 110 {
+		t.Errorf("kept %d of 1000 poll events, want about 100", counts["poll"])
+	}
+	if counts["job"] != 10 {
+		t.Errorf("kept %d of 10 job events, want all", counts["job"])
+	}
+}
+
+func TestEventSampleRateNormalizedName(t *testing.T) {
+	rec := setup(t)
+	SetEventNameNormalizer(func(name string) string {
+		base, _, _ := strings.Cut(name, ":")
+		return base
+	})
+	SetEventSampleRate("poll", 0)
+
+	s := New(context.Background(), "worker")
+	for i := range 10 {
+		s.Event(fmt.Sprintf("poll:%d", i)).Add()
+	}
+	s.End()
+
+	if n := len(onlySpan(t, rec).Events); n != 0 {
+		t.Errorf("kept %d poll events at rate 0, want none", n)
+	}
+}
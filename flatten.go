@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// AttrsFromNestedMap flattens m into string attributes with dotted keys
+// under prefix, e.g. {"db": {"host": "x"}} with prefix "cfg" becomes
+// cfg.db.host=x. Nested maps of any string-keyed type are followed.
+// Slices and arrays become indexed keys (cfg.hosts.0, cfg.hosts.1, ...)
+// rather than string slices, so elements that are themselves maps flatten
+// the same way. Other values are formatted with fmt.Sprint; nil values
+// are skipped.
+func AttrsFromNestedMap(m map[string]any, prefix string) *spanAttributes {
+	attrs := NewAttrs()
+	flatten(attrs, prefix, reflect.ValueOf(m))
+	return attrs
+}
+
+func flatten(attrs *spanAttributes, key string, v reflect.Value) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			attrs.StrKV(key, fmt.Sprint(v.Interface()))
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			flatten(attrs, joinKey(key, iter.Key().String()), iter.Value())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			flatten(attrs, joinKey(key, strconv.Itoa(i)), v.Index(i))
+		}
+	default:
+		attrs.StrKV(key, fmt.Sprint(v.Interface()))
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,35 @@
+package tracer
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestAttrsFromNestedMap(t *testing.T) {
+	m := map[string]any{
+		"db": map[string]string{"host": "db.internal", "port": "5432"},
+		"cache": map[string]any{
+			"ttl":   30,
+			"hosts": []string{"a", "b"},
+		},
+		"unset": nil,
+	}
+
+	got := attrMap(AttrsFromNestedMap(m, "cfg").Parse())
+	want := map[string]string{
+		"cfg.db.host":       "db.internal",
+		"cfg.db.port":       "5432",
+		"cfg.cache.ttl":     "30",
+		"cfg.cache.hosts.0": "a",
+		"cfg.cache.hosts.1": "b",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d attributes, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if s := got[attribute.Key(k)].AsString(); s != v {
+			t.Errorf("%s = %q, want %q", k, s, v)
+		}
+	}
+}
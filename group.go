@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// TaskGroup is an errgroup whose tasks each run in their own span.
+type TaskGroup struct {
+	eg   *errgroup.Group
+	ctx  context.Context
+	name string
+	opts []Option
+}
+
+// Group returns a TaskGroup and the context its tasks derive from, which
+// is canceled when a task fails. Task spans are named name and are
+// children of the span in ctx, using its tracer name and provider.
+func Group(ctx context.Context, name string) (*TaskGroup, context.Context) {
+	var opts []Option
+	if parent, ok := LoadFromContext(ctx); ok {
+		opts = append(opts, WithTracerName(parent.tracerName), WithProvider(parent.provider))
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	return &TaskGroup{eg: eg, ctx: ctx, name: name, opts: opts}, ctx
+}
+
+// Go runs fn in a new goroutine inside a child span, recording its error
+// on that span before ending it. A panic in fn is recorded and becomes
+// the task's error, as with Handler.
+func (g *TaskGroup) Go(fn func(ctx context.Context) error) {
+	g.eg.Go(func() error {
+		return runInSpan(New(g.ctx, g.name, g.opts...), fn)
+	})
+}
+
+// Wait blocks until all tasks finish and returns the first error.
+func (g *TaskGroup) Wait() error {
+	return g.eg.Wait()
+}
@@ -0,0 +1,63 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestGroup(t *testing.T) {
+	rec := setup(t)
+	errTask := errors.New("task failed")
+
+	parent := New(context.Background(), "fanout", WithTracerName("worker"))
+	g, _ := Group(parent.Ctx, "task")
+	for i := range 3 {
+		g.Go(func(ctx context.Context) error {
+			if i == 1 {
+				return errTask
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("Wait = %v, want %v", err, errTask)
+	}
+	parent.End()
+
+	failed := 0
+	for _, s := range rec.Spans() {
+		if s.Name != "task" {
+			continue
+		}
+		if s.Parent.SpanID() != parent.SpanIDRaw() {
+			t.Errorf("task parent = %s, want %s", s.Parent.SpanID(), parent.SpanID())
+		}
+		if s.InstrumentationScope.Name != "worker" {
+			t.Errorf("task scope = %q, want worker", s.InstrumentationScope.Name)
+		}
+		if s.Status.Code == codes.Error {
+			failed++
+		}
+	}
+	if n := len(rec.Spans()) - 1; n != 3 || failed != 1 {
+		t.Errorf("got %d task spans with %d failed, want 3 with 1 failed", n, failed)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	rec := setup(t)
+
+	g, _ := Group(context.Background(), "task")
+	g.Go(func(context.Context) error { panic("boom") })
+	err := g.Wait()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Wait = %v, want the recovered panic", err)
+	}
+	if code := onlySpan(t, rec).Status.Code; code != codes.Error {
+		t.Errorf("status = %v, want Error", code)
+	}
+}
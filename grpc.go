@@ -0,0 +1,74 @@
+package tracer
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/metadata"
+)
+
+// GRPCAttrs builds rpc.* attributes from a gRPC full method name
+// ("/package.Service/Method") and its status code.
+func GRPCAttrs(fullMethod string, statusCode int) *spanAttributes {
+	attrs := NewAttrs().StrKV("rpc.system", "grpc")
+
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if service := name[:i]; service != "" {
+			attrs.StrKV("rpc.service", service)
+		}
+		if method := name[i+1:]; method != "" {
+			attrs.StrKV("rpc.method", method)
+		}
+	} else if name != "" {
+		attrs.StrKV("rpc.method", name)
+	}
+
+	return attrs.IntKV("rpc.grpc.status_code", statusCode)
+}
+
+// MetadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type MetadataCarrier metadata.MD
+
+func (c MetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c MetadataCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
+// ExtractGRPC returns ctx carrying the remote span context, and baggage,
+// found in the incoming gRPC metadata of ctx, using the global propagator.
+// Spans started from it continue the caller's trace.
+func ExtractGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, MetadataCarrier(md))
+}
+
+// InjectGRPC returns ctx with the span context of ctx added to its
+// outgoing gRPC metadata, using the global propagator.
+func InjectGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, MetadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
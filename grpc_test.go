@@ -0,0 +1,50 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCAttrs(t *testing.T) {
+	got := attrMap(GRPCAttrs("/shop.v1.Cart/AddItem", 5).Parse())
+
+	for key, want := range map[string]string{
+		"rpc.system":  "grpc",
+		"rpc.service": "shop.v1.Cart",
+		"rpc.method":  "AddItem",
+	} {
+		if v := got[attribute.Key(key)].AsString(); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	if v := got["rpc.grpc.status_code"].AsInt64(); v != 5 {
+		t.Errorf("rpc.grpc.status_code = %d, want 5", v)
+	}
+}
+
+func TestGRPCMetadataRoundTrip(t *testing.T) {
+	rec := setup(t)
+
+	client := New(context.Background(), "client", WithKind("client"))
+	out := InjectGRPC(metadata.AppendToOutgoingContext(client.Ctx, "tenant", "acme"))
+	md, _ := metadata.FromOutgoingContext(out)
+	if len(md.Get("traceparent")) != 1 || md.Get("tenant")[0] != "acme" {
+		t.Fatalf("outgoing metadata = %v, want traceparent added to tenant", md)
+	}
+
+	in := ExtractGRPC(metadata.NewIncomingContext(context.Background(), md))
+	server := New(in, "server", WithKind("server"))
+	server.End()
+	client.End()
+
+	got := spanNamed(t, rec, "server")
+	if got.Parent.TraceID() != client.TraceIDRaw() || got.Parent.SpanID() != client.Span.SpanContext().SpanID() {
+		t.Errorf("server parent = %s/%s, want the client span", got.Parent.TraceID(), got.Parent.SpanID())
+	}
+	if !got.Parent.IsRemote() {
+		t.Error("server parent is not remote")
+	}
+}
@@ -0,0 +1,32 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Handler wraps h so each call runs in a span named spanName, started
+// from the call's context, with h's error recorded on it. A panic in h is
+// recorded and returned as an error, or re-raised when SetRepanic is on.
+func Handler(spanName string, h func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return runInSpan(New(ctx, spanName), h)
+	}
+}
+
+// runInSpan calls fn with s.Ctx and ends s, recording fn's error or
+// turning a panic into the returned error unless SetRepanic is on.
+func runInSpan(s *Span, fn func(context.Context) error) (err error) {
+	defer func() {
+		r := recover()
+		switch {
+		case r == nil:
+			s.Error(err, true)
+		case !cfg.repanic:
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+		s.end(r)
+	}()
+
+	return fn(s.Ctx)
+}
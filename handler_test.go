@@ -0,0 +1,48 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestHandler(t *testing.T) {
+	rec := setup(t)
+	calls := 0
+	h := Handler("job", func(ctx context.Context) error {
+		calls++
+		if _, ok := LoadFromContext(ctx); !ok {
+			t.Error("handler context has no span")
+		}
+		switch calls {
+		case 2:
+			return errors.New("failed")
+		case 3:
+			panic("boom")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	if err := h(ctx); err != nil {
+		t.Errorf("first call: %v", err)
+	}
+	if err := h(ctx); err == nil || err.Error() != "failed" {
+		t.Errorf("second call = %v, want failed", err)
+	}
+	if err := h(ctx); err == nil {
+		t.Error("panicking call returned no error")
+	}
+
+	spans := rec.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want one per call", len(spans))
+	}
+	for i, want := range []codes.Code{codes.Unset, codes.Error, codes.Error} {
+		if spans[i].Name != "job" || spans[i].Status.Code != want {
+			t.Errorf("span %d = %q %v, want job %v", i, spans[i].Name, spans[i].Status.Code, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tracer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AddLinkFromHeaders extracts a span context from h using the global
+// propagator and adds it as a link instead of a parent.
+func (s *Span) AddLinkFromHeaders(h http.Header, attrs ...*spanAttributes) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(h))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	attr := []attribute.KeyValue{}
+	if len(attrs) > 0 && attrs[0] != nil {
+		attr = attrs[0].Parse()
+	}
+
+	s.addLink(trace.Link{SpanContext: sc, Attributes: attr})
+}
+
+const truncatedMarker = "...truncated"
+
+// RecordBody attaches up to limit bytes of body as the key attribute and
+// its full size as <key>.length. The cut is moved back to a rune boundary
+// so multi-byte characters are not split. A limit <= 0 records the whole
+// body.
+func (s *Span) RecordBody(key string, body []byte, limit int) {
+	value := string(body)
+	if limit > 0 && len(body) > limit {
+		for limit > 0 && !utf8.RuneStart(body[limit]) {
+			limit--
+		}
+		value = string(body[:limit]) + truncatedMarker
+	}
+
+	s.Attrs.StrKV(key, value).IntKV(key+".length", len(body))
+}
+
+// RecordTransfer records the request and response body sizes as
+// http.request_content_length and http.response_content_length, and the
+// response Content-Encoding, when not empty, as
+// http.response.header.content_encoding.
+func (s *Span) RecordTransfer(requestBytes, responseBytes int64, encoding string) {
+	s.Attrs.
+		ValueKV("http.request_content_length", attribute.Int64Value(requestBytes)).
+		ValueKV("http.response_content_length", attribute.Int64Value(responseBytes))
+	if encoding != "" {
+		s.Attrs.StrKV("http.response.header.content_encoding", encoding)
+	}
+}
+
+// AttrsFromValues records the named query parameters as string attributes,
+// joining repeated values with commas and skipping absent keys. Values go
+// through the redactor like any other string attribute.
+func AttrsFromValues(v url.Values, keys ...string) *spanAttributes {
+	attrs := NewAttrs()
+	for _, k := range keys {
+		values, ok := v[k]
+		if !ok {
+			continue
+		}
+		attrs.StrKV(k, strings.Join(values, ","))
+	}
+	return attrs
+}
+
+// HTTPMiddlewareWithRoute traces each request in a server span named
+// "<method> <route>", where route comes from routeFn (typically the mux
+// pattern) to keep span names low-cardinality. An empty route names the
+// span after the method alone. The Span is stored in the request context.
+// A panic in next is recorded with status code 500 and re-raised so
+// net/http aborts the response instead of sending an implicit 200.
+func HTTPMiddlewareWithRoute(routeFn func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := ""
+		if routeFn != nil {
+			route = routeFn(r)
+		}
+		name := r.Method
+		if route != "" {
+			name = r.Method + " " + route
+		}
+
+		s := New(ctx, name, WithKind("server"))
+		defer func() {
+			p := recover()
+			if p != nil {
+				s.Attrs.IntKV("http.response.status_code", http.StatusInternalServerError)
+			}
+			s.end(p)
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		s.Attrs.HTTPMethodKV(r.Method)
+		if route != "" {
+			s.Attrs.StrKV("http.route", route)
+		}
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(StoreInContext(s.Ctx, s)))
+
+		s.Attrs.IntKV("http.response.status_code", rw.status)
+		if rw.status >= http.StatusInternalServerError {
+			s.SError(http.StatusText(rw.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush and Hijack keep http.Flusher and http.Hijacker visible to
+// handlers that assert them directly, e.g. for server-sent events or
+// WebSockets. They go through http.ResponseController: Flush does nothing
+// and Hijack returns an error wrapping http.ErrNotSupported when the
+// underlying writer lacks them.
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// RateLimited records a rate-limit response with the server's retry-after
+// hint as rate_limited=true and retry_after_ms. See SetRateLimitedStatus.
+func (s *Span) RateLimited(retryAfter time.Duration) {
+	s.Attrs.BoolKV("rate_limited", true).IntKV("retry_after_ms", durationMs(retryAfter))
+	switch cfg.rateLimitedStatus {
+	case codes.Error:
+		s.SError("rate limited")
+	case codes.Ok:
+		s.OK("rate limited")
+	}
+}
+
+// HTTPRetry records one attempt of a retried request as an "http.retry"
+// event with http.retry.attempt, http.retry.status_code and, on failure,
+// error.message, and counts attempts in http.retry.count.
+func (s *Span) HTTPRetry(attempt int, statusCode int, err error) {
+	e := s.Event("http.retry").
+		Int("http.retry.attempt", attempt).
+		Int("http.retry.status_code", statusCode)
+	if err != nil {
+		e.Str("error.message", err.Error())
+	}
+	e.Add()
+
+	s.IncrAttr("http.retry.count", 1)
+}
+
+var knownHTTPMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodPost:    {},
+	http.MethodPut:     {},
+	http.MethodPatch:   {},
+	http.MethodDelete:  {},
+	http.MethodConnect: {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+}
+
+// HTTPMethodKV records http.request.method following otel conventions:
+// known methods are uppercased, unknown ones become _OTHER, and the input
+// is kept as http.request.method_original whenever it was not already the
+// canonical form.
+func (a *spanAttributes) HTTPMethodKV(method string) *spanAttributes {
+	normalized := strings.ToUpper(method)
+	if _, ok := knownHTTPMethods[normalized]; !ok {
+		normalized = "_OTHER"
+	}
+
+	a.StrKV("http.request.method", normalized)
+	if normalized != method {
+		a.StrKV("http.request.method_original", method)
+	}
+	return a
+}
@@ -0,0 +1,267 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestAddLinkFromHeaders(t *testing.T) {
+	rec := setup(t)
+
+	caller := New(context.Background(), "caller")
+	h := http.Header{}
+	otel.GetTextMapPropagator().Inject(caller.Ctx, propagation.HeaderCarrier(h))
+	caller.End()
+
+	s := New(context.Background(), "callee")
+	s.AddLinkFromHeaders(h, NewAttrs().StrKV("link.reason", "caller"))
+	s.End()
+
+	callee := spanNamed(t, rec, "callee")
+	if len(callee.Links) != 1 {
+		t.Fatalf("got %d links, want 1", len(callee.Links))
+	}
+	if got, want := callee.Links[0].SpanContext.TraceID(), caller.TraceIDRaw(); got != want {
+		t.Errorf("link trace ID = %s, want %s", got, want)
+	}
+	if callee.Parent.IsValid() {
+		t.Errorf("callee has parent %s, want none", callee.Parent.SpanID())
+	}
+}
+
+func TestRecordBody(t *testing.T) {
+	rec := setup(t)
+	AddValueRedactPattern(regexp.MustCompile(`secret-\w+`), "[REDACTED]")
+
+	s := New(context.Background(), "call")
+	s.RecordBody("http.response.body", []byte(`{"token":"secret-abc","items":[1,2,3]}`), 24)
+	s.RecordBody("greeting", []byte("héllo"), 2)
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if v, want := got["http.response.body"].AsString(), `{"token":"[REDACTED]","i...truncated`; v != want {
+		t.Errorf("body = %q, want %q", v, want)
+	}
+	if v := got["http.response.body.length"].AsInt64(); v != 38 {
+		t.Errorf("body length = %d, want 38", v)
+	}
+	if v, want := got["greeting"].AsString(), "h...truncated"; v != want {
+		t.Errorf("greeting = %q, want %q, cut on a rune boundary", v, want)
+	}
+}
+
+func TestAttrsFromValues(t *testing.T) {
+	setup(t)
+	SetRedactor(RedactKeys("token"))
+	v := url.Values{"tag": {"a", "b"}, "page": {"2"}, "token": {"s3cr3t"}}
+
+	got := attrMap(AttrsFromValues(v, "tag", "page", "token", "absent").Parse())
+	if s := got["tag"].AsString(); s != "a,b" {
+		t.Errorf("tag = %q, want a,b", s)
+	}
+	if s := got["page"].AsString(); s != "2" {
+		t.Errorf("page = %q, want 2", s)
+	}
+	if s := got["token"].AsString(); s != redactedValue {
+		t.Errorf("token = %q, want it redacted", s)
+	}
+	if _, ok := got["absent"]; ok {
+		t.Error("absent key was recorded")
+	}
+}
+
+func TestHTTPMiddlewareWithRoute(t *testing.T) {
+	rec := setup(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	h := HTTPMiddlewareWithRoute(func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return strings.TrimPrefix(pattern, r.Method+" ")
+	}, mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	span := spanNamed(t, rec, "GET /users/{id}")
+	if v := attrMap(span.Attributes)["http.route"].AsString(); v != "/users/{id}" {
+		t.Errorf("http.route = %q, want /users/{id}", v)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic in the handler was swallowed")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/boom", nil))
+	}()
+	span = spanNamed(t, rec, "GET /boom")
+	if v := attrMap(span.Attributes)["http.response.status_code"].AsInt64(); v != http.StatusInternalServerError {
+		t.Errorf("status code after panic = %d, want 500", v)
+	}
+}
+
+func TestHTTPMiddlewareKeepsFlusherAndHijacker(t *testing.T) {
+	setup(t)
+	h := HTTPMiddlewareWithRoute(func(r *http.Request) string { return r.URL.Path }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			f, ok := w.(http.Flusher)
+			if !ok {
+				t.Error("http.Flusher hidden by the middleware")
+				return
+			}
+			w.Write([]byte("data: 1\n\n"))
+			f.Flush()
+		case "/ws":
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Error("http.Hijacker hidden by the middleware")
+				return
+			}
+			conn, buf, err := hj.Hijack()
+			if err != nil {
+				t.Errorf("Hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+			buf.Flush()
+		}
+	}))
+	// Wait for the middleware to end its span: the hijacked response
+	// reaches the client first, and Close does not track hijacked conns.
+	var served sync.WaitGroup
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer served.Done()
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	defer served.Wait()
+
+	for path, want := range map[string]int{"/events": http.StatusOK, "/ws": http.StatusNoContent} {
+		served.Add(1)
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	// httptest.ResponseRecorder flushes but cannot be hijacked.
+	rw := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	rw.Flush()
+	if _, _, err := rw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack on a writer without it = %v, want http.ErrNotSupported", err)
+	}
+}
+
+func TestHTTPMiddlewareWithRouteEmpty(t *testing.T) {
+	rec := setup(t)
+	h := HTTPMiddlewareWithRoute(func(*http.Request) string { return "" }, http.NotFoundHandler())
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/anything/123", nil))
+	if name := onlySpan(t, rec).Name; name != "POST" {
+		t.Errorf("span name = %q, want POST", name)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "call")
+	s.RateLimited(30 * time.Second)
+	s.End()
+
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	if !got["rate_limited"].AsBool() || got["retry_after_ms"].AsInt64() != 30000 {
+		t.Errorf("attributes = %v, want rate_limited=true retry_after_ms=30000", span.Attributes)
+	}
+	if span.Status.Code == codes.Error {
+		t.Error("rate limiting set Error status by default")
+	}
+
+	SetRateLimitedStatus(codes.Error)
+	s = New(context.Background(), "strict")
+	s.RateLimited(time.Second)
+	s.End()
+	if code := spanNamed(t, rec, "strict").Status.Code; code != codes.Error {
+		t.Errorf("status with SetRateLimitedStatus(Error) = %v", code)
+	}
+}
+
+func TestHTTPMethodKV(t *testing.T) {
+	got := attrMap(NewAttrs().HTTPMethodKV("get").Parse())
+	if got["http.request.method"].AsString() != "GET" || got["http.request.method_original"].AsString() != "get" {
+		t.Errorf("get = %v", got)
+	}
+
+	got = attrMap(NewAttrs().HTTPMethodKV("PURGE").Parse())
+	if got["http.request.method"].AsString() != "_OTHER" || got["http.request.method_original"].AsString() != "PURGE" {
+		t.Errorf("PURGE = %v", got)
+	}
+
+	got = attrMap(NewAttrs().HTTPMethodKV("POST").Parse())
+	if _, ok := got["http.request.method_original"]; ok || got["http.request.method"].AsString() != "POST" {
+		t.Errorf("POST = %v", got)
+	}
+}
+
+func TestRecordTransfer(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "download")
+	s.RecordTransfer(128, 4096, "gzip")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if got["http.request_content_length"].AsInt64() != 128 || got["http.response_content_length"].AsInt64() != 4096 {
+		t.Errorf("content lengths = %v, %v", got["http.request_content_length"].Emit(), got["http.response_content_length"].Emit())
+	}
+	if v := got["http.response.header.content_encoding"].AsString(); v != "gzip" {
+		t.Errorf("content encoding = %q, want gzip", v)
+	}
+}
+
+func TestHTTPRetry(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "GET /items", WithKind("client"))
+	s.HTTPRetry(1, 503, errors.New("unavailable"))
+	s.HTTPRetry(2, 429, nil)
+	s.HTTPRetry(3, 200, nil)
+	s.End()
+
+	span := onlySpan(t, rec)
+	if len(span.Events) != 3 {
+		t.Fatalf("got %d events, want one per attempt", len(span.Events))
+	}
+	for i, status := range []int64{503, 429, 200} {
+		got := attrMap(span.Events[i].Attributes)
+		if span.Events[i].Name != "http.retry" || got["http.retry.attempt"].AsInt64() != int64(i+1) ||
+			got["http.retry.status_code"].AsInt64() != status {
+			t.Errorf("event %d = %s %v, want http.retry attempt %d status %d", i, span.Events[i].Name, got, i+1, status)
+		}
+	}
+	if v := attrMap(span.Events[0].Attributes)["error.message"].AsString(); v != "unavailable" {
+		t.Errorf("first attempt error.message = %q, want unavailable", v)
+	}
+	if v := attrMap(span.Attributes)["http.retry.count"].AsInt64(); v != 3 {
+		t.Errorf("http.retry.count = %d, want 3", v)
+	}
+}
@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type forcedSpanIDKey struct{}
+
+// forcedSpanID is consumed by the first span that reads it, so children
+// started from the same context get random IDs again.
+type forcedSpanID struct {
+	id   trace.SpanID
+	used atomic.Bool
+}
+
+// WithForcedSpanID makes the span use id when the provider was built with
+// ForcedIDGenerator. It is intended for deterministic tests only.
+func WithForcedSpanID(id trace.SpanID) Option {
+	return func(o *startOptions) { o.forcedSpanID = id }
+}
+
+// ForcedIDGenerator returns an ID generator, for use with
+// sdktrace.WithIDGenerator, that honors WithForcedSpanID and otherwise
+// generates random IDs.
+func ForcedIDGenerator() sdktrace.IDGenerator {
+	return forcedIDGenerator{}
+}
+
+type forcedIDGenerator struct{}
+
+func (g forcedIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	for !tid.IsValid() {
+		fillRandom(tid[:])
+	}
+	return tid, g.NewSpanID(ctx, tid)
+}
+
+func (forcedIDGenerator) NewSpanID(ctx context.Context, _ trace.TraceID) trace.SpanID {
+	if f, ok := ctx.Value(forcedSpanIDKey{}).(*forcedSpanID); ok && f.used.CompareAndSwap(false, true) {
+		return f.id
+	}
+
+	var sid trace.SpanID
+	for !sid.IsValid() {
+		fillRandom(sid[:])
+	}
+	return sid
+}
+
+func fillRandom(b []byte) {
+	for i := range b {
+		b[i] = byte(rand.Uint32())
+	}
+}
+
+func withForcedSpanID(ctx context.Context, id trace.SpanID) context.Context {
+	if !id.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, forcedSpanIDKey{}, &forcedSpanID{id: id})
+}
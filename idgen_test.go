@@ -0,0 +1,26 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithForcedSpanID(t *testing.T) {
+	rec := setup(t, sdktrace.WithIDGenerator(ForcedIDGenerator()))
+	id := trace.SpanID{0xde, 0xad, 0xbe, 0xef, 0, 0, 0, 1}
+
+	s := New(context.Background(), "golden", WithForcedSpanID(id))
+	child := s.Child("child")
+	child.End()
+	s.End()
+
+	if got := spanNamed(t, rec, "golden").SpanContext.SpanID(); got != id {
+		t.Errorf("span ID = %s, want %s", got, id)
+	}
+	if got := spanNamed(t, rec, "child").SpanContext.SpanID(); got == id || !got.IsValid() {
+		t.Errorf("child span ID = %s, want a new random ID", got)
+	}
+}
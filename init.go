@@ -0,0 +1,172 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type initConfig struct {
+	serviceName string
+	endpoint    string
+	insecure    bool
+	sampler     sdktrace.Sampler
+	fallback    bool
+	headers     map[string]string
+}
+
+type InitOption func(*initConfig)
+
+func WithServiceName(name string) InitOption {
+	return func(c *initConfig) { c.serviceName = name }
+}
+
+// WithEndpoint sets the OTLP/HTTP collector address as host:port.
+func WithEndpoint(endpoint string) InitOption {
+	return func(c *initConfig) { c.endpoint = endpoint }
+}
+
+func WithInsecure() InitOption {
+	return func(c *initConfig) { c.insecure = true }
+}
+
+func WithSampler(sampler sdktrace.Sampler) InitOption {
+	return func(c *initConfig) { c.sampler = sampler }
+}
+
+// WithOTLPHeaders sets headers sent with every export request, e.g. an
+// API key for the collector. They are never logged or reported by
+// ConfigSnapshot.
+func WithOTLPHeaders(headers map[string]string) InitOption {
+	return func(c *initConfig) { c.headers = maps.Clone(headers) }
+}
+
+// WithFallbackOnError makes Init install a provider without an exporter
+// when the exporter cannot be set up or the collector does not accept a
+// TCP connection within two seconds, instead of returning the
+// error. Spans are still created and processed in-process but never
+// exported; the original error is reported through otel.Handle.
+func WithFallbackOnError() InitOption {
+	return func(c *initConfig) { c.fallback = true }
+}
+
+// Init installs a global tracer provider exporting over OTLP/HTTP and the
+// W3C trace context and baggage propagators. The returned func flushes and
+// shuts the provider down.
+func Init(ctx context.Context, opts ...InitOption) (func(context.Context) error, error) {
+	c := initConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	res, err := c.resource()
+	if err != nil {
+		return nil, err
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if c.sampler != nil {
+		providerOpts = append(providerOpts, sdktrace.WithSampler(c.sampler))
+	}
+
+	exporter, err := c.exporter(ctx)
+	if err == nil && c.fallback {
+		if err = dialCollector(ctx, c.collectorAddr()); err != nil {
+			_ = exporter.Shutdown(ctx)
+		}
+	}
+	switch {
+	case err == nil:
+		if len(cfg.promotedKeys) > 0 {
+			exporter = newPromotingExporter(exporter, maps.Clone(cfg.promotedKeys))
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	case c.fallback:
+		otel.Handle(err)
+	default:
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp.Shutdown, nil
+}
+
+func (c initConfig) resource() (*resource.Resource, error) {
+	if c.serviceName == "" {
+		return resource.Default(), nil
+	}
+
+	return resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", c.serviceName),
+	))
+}
+
+func (c initConfig) exporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{}
+	if c.endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(c.endpoint))
+	}
+	if c.insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(c.headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(c.headers))
+	}
+
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// collectorDialTimeout bounds the reachability check WithFallbackOnError
+// does at startup.
+const collectorDialTimeout = 2 * time.Second
+
+func dialCollector(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, collectorDialTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("collector %s unreachable: %w", addr, err)
+	}
+	return conn.Close()
+}
+
+// collectorAddr returns the host:port the exporter sends to, following
+// the same precedence as otlptracehttp: WithEndpoint, then the OTLP
+// endpoint environment variables, then localhost:4318.
+func (c initConfig) collectorAddr() string {
+	if c.endpoint != "" {
+		return c.endpoint
+	}
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"} {
+		u, err := url.Parse(os.Getenv(env))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if u.Port() != "" {
+			return u.Host
+		}
+		if u.Scheme == "https" {
+			return net.JoinHostPort(u.Hostname(), "443")
+		}
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return "localhost:4318"
+}
@@ -0,0 +1,97 @@
+package tracer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// closedAddr returns a local address nothing listens on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+type errorHandlerFunc func(error)
+
+func (f errorHandlerFunc) Handle(err error) { f(err) }
+
+func TestInitFallbackOnDialFailure(t *testing.T) {
+	setup(t)
+	var handled []error
+	prev := otel.GetErrorHandler()
+	otel.SetErrorHandler(errorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(prev) })
+
+	ctx := context.Background()
+	shutdown, err := Init(ctx, WithEndpoint(closedAddr(t)), WithInsecure(), WithFallbackOnError())
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	defer shutdown(ctx)
+
+	if len(handled) != 1 {
+		t.Errorf("otel.Handle got %v, want the dial error", handled)
+	}
+	s := New(ctx, "after-fallback")
+	defer s.End()
+	if !s.Span.IsRecording() || !s.TraceIDRaw().IsValid() {
+		t.Error("span from the fallback provider is not recording")
+	}
+}
+
+func TestInitWithoutFallbackSkipsDial(t *testing.T) {
+	setup(t)
+
+	shutdown, err := Init(context.Background(), WithEndpoint(closedAddr(t)), WithInsecure())
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	shutdown(context.Background())
+}
+
+func TestInitOTLPHeaders(t *testing.T) {
+	setup(t)
+	var (
+		mu      sync.Mutex
+		apiKeys []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		apiKeys = append(apiKeys, r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	shutdown, err := Init(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithOTLPHeaders(map[string]string{"X-Api-Key": "k-123"}))
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	New(ctx, "op").End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(apiKeys) == 0 || apiKeys[0] != "k-123" {
+		t.Errorf("export requests carried X-Api-Key %q, want k-123", apiKeys)
+	}
+}
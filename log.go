@@ -0,0 +1,29 @@
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ShouldLog reports whether the span active in ctx is sampled.
+func ShouldLog(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsSampled()
+}
+
+// LogEveryN reports true for sampled spans and for a deterministic 1/n of
+// unsampled traces, chosen by trace ID so every log line of a trace gets
+// the same answer.
+func LogEveryN(ctx context.Context, n int) bool {
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.IsSampled() {
+		return true
+	}
+	if !sc.HasTraceID() || n <= 0 {
+		return false
+	}
+
+	id := sc.TraceID()
+	return binary.BigEndian.Uint64(id[8:])%uint64(n) == 0
+}
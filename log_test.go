@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanContextWithTraceID(low uint64, sampled bool) context.Context {
+	var tid trace.TraceID
+	tid[0] = 1
+	binary.BigEndian.PutUint64(tid[8:], low)
+	scc := trace.SpanContextConfig{TraceID: tid, SpanID: trace.SpanID{1}}
+	if sampled {
+		scc.TraceFlags = trace.FlagsSampled
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(scc))
+}
+
+func TestShouldLog(t *testing.T) {
+	if !ShouldLog(spanContextWithTraceID(7, true)) {
+		t.Error("ShouldLog = false for a sampled span")
+	}
+	if ShouldLog(spanContextWithTraceID(7, false)) {
+		t.Error("ShouldLog = true for an unsampled span")
+	}
+	if ShouldLog(context.Background()) {
+		t.Error("ShouldLog = true without a span")
+	}
+}
+
+func TestLogEveryN(t *testing.T) {
+	for i := range uint64(20) {
+		if !LogEveryN(spanContextWithTraceID(i, true), 4) {
+			t.Fatalf("LogEveryN = false for sampled trace %d", i)
+		}
+	}
+
+	logged := 0
+	for i := range uint64(400) {
+		ctx := spanContextWithTraceID(i, false)
+		got := LogEveryN(ctx, 4)
+		if got != LogEveryN(ctx, 4) {
+			t.Fatalf("LogEveryN is not deterministic for trace %d", i)
+		}
+		if got {
+			logged++
+		}
+	}
+	if logged != 100 {
+		t.Errorf("logged %d of 400 unsampled traces, want 100", logged)
+	}
+}
@@ -0,0 +1,10 @@
+package tracer
+
+// KafkaProduced records the partition, key and payload size of a message
+// produced to Kafka. The key goes through the redactor.
+func (s *Span) KafkaProduced(partition int, key string, size int) {
+	s.Attrs.StrKV("messaging.system", "kafka").
+		IntKV("messaging.kafka.partition", partition).
+		StrKV("messaging.kafka.message.key", key).
+		IntKV("messaging.message.payload_size_bytes", size)
+}
@@ -0,0 +1,26 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKafkaProduced(t *testing.T) {
+	rec := setup(t)
+	SetRedactor(RedactKeys("messaging.kafka.message.key"))
+
+	s := New(context.Background(), "orders publish", WithKind("producer"))
+	s.KafkaProduced(3, "customer-17", 512)
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if v := got["messaging.kafka.partition"].AsInt64(); v != 3 {
+		t.Errorf("partition = %d, want 3", v)
+	}
+	if v := got["messaging.kafka.message.key"].AsString(); v != redactedValue {
+		t.Errorf("message key = %q, want it redacted", v)
+	}
+	if v := got["messaging.message.payload_size_bytes"].AsInt64(); v != 512 {
+		t.Errorf("payload size = %d, want 512", v)
+	}
+}
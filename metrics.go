@@ -0,0 +1,50 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+type selfMetrics struct {
+	created metric.Int64Counter
+	active  metric.Int64UpDownCounter
+	ended   metric.Int64Counter
+}
+
+// EnableSelfMetrics registers instruments on meter counting the spans
+// this package creates: tracer.spans.created and tracer.spans.ended, and
+// tracer.spans.active for spans started but not yet ended. No-op spans
+// are not counted, and a span is only counted as ended by the
+// instruments that counted its start.
+func EnableSelfMetrics(meter metric.Meter) error {
+	created, err1 := meter.Int64Counter("tracer.spans.created",
+		metric.WithDescription("Spans started."))
+	active, err2 := meter.Int64UpDownCounter("tracer.spans.active",
+		metric.WithDescription("Spans started and not yet ended."))
+	ended, err3 := meter.Int64Counter("tracer.spans.ended",
+		metric.WithDescription("Spans ended."))
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return err
+	}
+
+	cfg.selfMetrics = &selfMetrics{created: created, active: active, ended: ended}
+	return nil
+}
+
+func (m *selfMetrics) spanStarted() {
+	if m == nil {
+		return
+	}
+	m.created.Add(context.Background(), 1)
+	m.active.Add(context.Background(), 1)
+}
+
+func (m *selfMetrics) spanEnded() {
+	if m == nil {
+		return
+	}
+	m.active.Add(context.Background(), -1)
+	m.ended.Add(context.Background(), 1)
+}
@@ -0,0 +1,97 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// sumMeter is an in-memory metric.Meter that keeps the running sum of
+// each int64 counter and up-down counter by name.
+type sumMeter struct {
+	noop.Meter
+	mu   sync.Mutex
+	sums map[string]int64
+}
+
+func newSumMeter() *sumMeter { return &sumMeter{sums: map[string]int64{}} }
+
+func (m *sumMeter) sum(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sums[name]
+}
+
+func (m *sumMeter) add(name string, n int64) {
+	m.mu.Lock()
+	m.sums[name] += n
+	m.mu.Unlock()
+}
+
+func (m *sumMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return sumCounter{m: m, name: name}, nil
+}
+
+func (m *sumMeter) Int64UpDownCounter(name string, _ ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	return sumUpDownCounter{m: m, name: name}, nil
+}
+
+type sumCounter struct {
+	noop.Int64Counter
+	m    *sumMeter
+	name string
+}
+
+func (c sumCounter) Add(_ context.Context, n int64, _ ...metric.AddOption) { c.m.add(c.name, n) }
+
+type sumUpDownCounter struct {
+	noop.Int64UpDownCounter
+	m    *sumMeter
+	name string
+}
+
+func (c sumUpDownCounter) Add(_ context.Context, n int64, _ ...metric.AddOption) { c.m.add(c.name, n) }
+
+func TestSelfMetrics(t *testing.T) {
+	setup(t)
+	meter := newSumMeter()
+	if err := EnableSelfMetrics(meter); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 25 {
+				s := New(context.Background(), "op")
+				s.Child("child").End()
+				s.End()
+				s.End()
+			}
+		}()
+	}
+	held := New(context.Background(), "held")
+	wg.Wait()
+
+	if n := meter.sum("tracer.spans.active"); n != 1 {
+		t.Errorf("active = %d while one span is running, want 1", n)
+	}
+	held.End()
+	// Spans the package did not start are not counted, so ending them
+	// cannot push the active count below zero.
+	MustFromContext(context.Background()).End()
+	SetStrictTracerName(true)
+	New(context.Background(), "unnamed").End()
+
+	if n := meter.sum("tracer.spans.active"); n != 0 {
+		t.Errorf("active = %d after all spans ended, want 0", n)
+	}
+	if c, e := meter.sum("tracer.spans.created"), meter.sum("tracer.spans.ended"); c != 201 || e != 201 {
+		t.Errorf("created = %d, ended = %d, want 201 each", c, e)
+	}
+}
@@ -0,0 +1,38 @@
+package tracer
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Outcome is a compact summary of an ended span.
+type Outcome struct {
+	Name              string
+	Duration          time.Duration
+	Status            codes.Code
+	StatusDescription string
+	ErrorCount        int
+	EventCount        int
+}
+
+// SetOutcomeHook sets a callback End invokes with the span's Outcome, for
+// in-process aggregation without an exporter.
+func SetOutcomeHook(fn func(Outcome)) {
+	cfg.outcomeHook = fn
+}
+
+func (s *Span) reportOutcome(end time.Time) {
+	if cfg.outcomeHook == nil {
+		return
+	}
+
+	cfg.outcomeHook(Outcome{
+		Name:              s.name,
+		Duration:          end.Sub(s.start),
+		Status:            s.status,
+		StatusDescription: s.statusDesc,
+		ErrorCount:        s.errorCount,
+		EventCount:        int(s.eventCount.Load()),
+	})
+}
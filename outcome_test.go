@@ -0,0 +1,41 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestOutcomeHook(t *testing.T) {
+	setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+	var outcomes []Outcome
+	SetOutcomeHook(func(o Outcome) { outcomes = append(outcomes, o) })
+
+	s := New(context.Background(), "charge")
+	s.Event("attempt").Add()
+	s.Event("attempt").Add()
+	s.Error(errors.New("card declined"))
+	clock.Advance(40 * time.Millisecond)
+	s.End()
+	s.End()
+
+	if len(outcomes) != 1 {
+		t.Fatalf("hook ran %d times, want once", len(outcomes))
+	}
+	want := Outcome{
+		Name:              "charge",
+		Duration:          40 * time.Millisecond,
+		Status:            codes.Error,
+		StatusDescription: "card declined",
+		ErrorCount:        1,
+		EventCount:        2,
+	}
+	if outcomes[0] != want {
+		t.Errorf("outcome = %+v, want %+v", outcomes[0], want)
+	}
+}
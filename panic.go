@@ -0,0 +1,142 @@
+package tracer
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetPanicFunc sets a hook run after a recovered panic has been recorded
+// on the span, e.g. for logging.
+func SetPanicFunc(fn func(s *Span, recovered any)) {
+	cfg.panicFunc = fn
+}
+
+// SetRepanic makes End and Recover panic again with the original value
+// once the panic has been recorded, so upstream handlers still see it.
+func SetRepanic(enabled bool) {
+	cfg.repanic = enabled
+}
+
+// SetRepanicAfterRecord is an alias of SetRepanic. The original panic
+// value is re-raised as is, not wrapped.
+func SetRepanicAfterRecord(enabled bool) {
+	SetRepanic(enabled)
+}
+
+// Recover records a panic on the span without ending it. It must be
+// deferred directly: defer s.Recover(). End already recovers on its own,
+// so Recover is for spans ended elsewhere or for code that should keep
+// running after the panic.
+//
+// A panic stops at the first deferred End or Recover that sees it unless
+// SetRepanic is enabled. When it is re-raised through nested spans, only
+// the innermost span records the exception; each enclosing span started
+// from its context gets Error status and child_panicked=true instead, and
+// a span never records the same panic twice. A different panic reaching
+// an enclosing span, e.g. its own after a child in another goroutine
+// panicked and was recovered there, is recorded in full.
+func (s *Span) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	s.recordPanic(r)
+	if cfg.repanic {
+		panic(r)
+	}
+}
+
+// childPanic is the value a descendant span recorded and re-raised, kept
+// on its ancestors so they can tell the same panic from a new one.
+type childPanic struct{ value any }
+
+func (s *Span) recordPanic(r any) {
+	err := fmt.Errorf("recovered from panic: %v", r)
+	if cp := s.childPanic.Load(); cp != nil && samePanic(cp.value, r) {
+		if !s.panicked.Swap(true) {
+			s.Span.SetAttributes(attribute.Bool("child_panicked", true))
+			s.Error(err)
+		}
+		return
+	}
+	if s.panicked.Swap(true) {
+		return
+	}
+
+	stack := string(debug.Stack())
+	var stackOpt trace.EventOption = trace.WithStackTrace(true)
+	if cfg.errorStackDepth > 0 {
+		stack = captureStack(cfg.errorStackDepth)
+		stackOpt = trace.WithAttributes(attribute.String("exception.stacktrace", stack))
+	}
+	s.Span.RecordError(err, stackOpt)
+	s.Span.SetAttributes(
+		attribute.String("panic.type", fmt.Sprintf("%T", r)),
+		attribute.String("panic.value", fmt.Sprint(r)),
+		attribute.String("panic.stack", stack),
+	)
+	s.Error(err)
+
+	if cfg.repanic {
+		for p := s.parent; p != nil; p = p.parent {
+			p.childPanic.Store(&childPanic{value: r})
+		}
+	}
+
+	if cfg.panicFunc != nil {
+		cfg.panicFunc(s, r)
+	}
+}
+
+// samePanic reports whether a and b are the same panic value. Values of
+// types that cannot be compared are never the same.
+func samePanic(a, b any) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// SetErrorStackDepth limits the stacks recorded for panics, both the
+// exception.stacktrace of the exception event and panic.stack, to the
+// innermost n frames, which are much smaller than otel's full stack.
+// A value <= 0 records the full stack.
+func SetErrorStackDepth(n int) {
+	cfg.errorStackDepth = n
+}
+
+// captureStack formats up to n frames of the panicking goroutine's stack
+// in the layout of debug.Stack, starting at the frame that panicked rather
+// than in the recovering code.
+func captureStack(n int) string {
+	pcs := make([]uintptr, n+32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+
+	var all []runtime.Frame
+	frames := runtime.CallersFrames(pcs)
+	for more := len(pcs) > 0; more; {
+		var f runtime.Frame
+		f, more = frames.Next()
+		all = append(all, f)
+	}
+	for i, f := range all {
+		if f.Function == "runtime.gopanic" {
+			all = all[i+1:]
+			break
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range all[:min(n, len(all))] {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
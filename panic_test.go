@@ -0,0 +1,221 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestRecoverStandalone(t *testing.T) {
+	rec := setup(t)
+	var hooked any
+	SetPanicFunc(func(_ *Span, r any) { hooked = r })
+
+	s := New(context.Background(), "op")
+	func() {
+		defer s.Recover()
+		panic("boom")
+	}()
+	if s.Ended() {
+		t.Error("Recover ended the span")
+	}
+	s.End()
+
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	if got["panic.type"].AsString() != "string" || got["panic.value"].AsString() != "boom" || got["panic.stack"].AsString() == "" {
+		t.Errorf("panic attributes = %v", span.Attributes)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+	if hooked != "boom" {
+		t.Errorf("panic func got %v, want boom", hooked)
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	setup(t)
+	SetRepanic(true)
+
+	s := New(context.Background(), "op")
+	defer s.End()
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want boom re-raised", r)
+		}
+	}()
+	defer s.Recover()
+	panic("boom")
+}
+
+func TestEndPanicExtractsAttrs(t *testing.T) {
+	rec := setup(t)
+
+	func() {
+		s := New(context.Background(), "op")
+		defer s.End()
+		s.Attrs.StrKV("request.id", "r-1")
+		s.CacheEvent("k", true)
+		panic("boom")
+	}()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if got["request.id"].AsString() != "r-1" || got["cache.hits"].AsInt64() != 1 {
+		t.Errorf("attributes after a recovered panic = %v", got)
+	}
+}
+
+func TestNestedPanicRecordedOnce(t *testing.T) {
+	rec := setup(t)
+	SetRepanic(true)
+
+	func() {
+		defer func() { recover() }()
+
+		outer := New(context.Background(), "outer")
+		defer outer.End()
+		inner := New(outer.Ctx, "inner")
+		defer inner.End()
+		panic("boom")
+	}()
+
+	inner, outer := spanNamed(t, rec, "inner"), spanNamed(t, rec, "outer")
+	if len(inner.Events) != 1 || inner.Events[0].Name != "exception" {
+		t.Errorf("inner events = %v, want one exception", inner.Events)
+	}
+	if len(outer.Events) != 0 {
+		t.Errorf("outer events = %v, want none", outer.Events)
+	}
+	if !attrMap(outer.Attributes)["child_panicked"].AsBool() {
+		t.Error("outer span is missing child_panicked")
+	}
+	if _, ok := attrMap(outer.Attributes)["panic.value"]; ok {
+		t.Error("outer span recorded the panic value")
+	}
+	if outer.Status.Code != codes.Error {
+		t.Errorf("outer status = %v, want Error", outer.Status.Code)
+	}
+}
+
+func TestPanicAfterRecoveredChildPanic(t *testing.T) {
+	rec := setup(t)
+	SetRepanic(true)
+
+	func() {
+		defer func() { recover() }()
+
+		parent := New(context.Background(), "parent")
+		defer parent.End()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer func() { recover() }()
+			child := parent.Child("child")
+			defer child.End()
+			panic("child boom")
+		}()
+		<-done
+		panic("parent boom")
+	}()
+
+	parent := spanNamed(t, rec, "parent")
+	if parent.Status.Code != codes.Error {
+		t.Errorf("parent status = %v, want Error", parent.Status.Code)
+	}
+	if len(parent.Events) != 1 || parent.Events[0].Name != "exception" {
+		t.Errorf("parent events = %v, want its own exception", parent.Events)
+	}
+	if v := attrMap(parent.Attributes)["panic.value"].AsString(); v != "parent boom" {
+		t.Errorf("parent panic.value = %q, want parent boom", v)
+	}
+}
+
+type panicValue struct{ code int }
+
+func TestRepanicAfterRecord(t *testing.T) {
+	rec := setup(t)
+
+	run := func() (recovered any) {
+		defer func() { recovered = recover() }()
+		s := New(context.Background(), "op")
+		defer s.End()
+		panic(panicValue{code: 7})
+	}
+
+	if r := run(); r != nil {
+		t.Errorf("panic propagated past End without repanic: %v", r)
+	}
+	SetRepanicAfterRecord(true)
+	if r := run(); r != (panicValue{code: 7}) {
+		t.Errorf("recovered %#v, want the original panicValue", r)
+	}
+	for _, s := range rec.Spans() {
+		if s.Status.Code != codes.Error {
+			t.Errorf("span status = %v, want Error", s.Status.Code)
+		}
+	}
+}
+
+func TestErrorStackDepth(t *testing.T) {
+	rec := setup(t)
+	SetErrorStackDepth(2)
+
+	func() {
+		s := New(context.Background(), "op")
+		defer s.End()
+		panic("boom")
+	}()
+
+	span := onlySpan(t, rec)
+	stacks := []string{attrMap(span.Attributes)["panic.stack"].AsString()}
+	for _, e := range span.Events {
+		if e.Name == "exception" {
+			stacks = append(stacks, attrMap(e.Attributes)["exception.stacktrace"].AsString())
+		}
+	}
+	if len(stacks) != 2 {
+		t.Fatalf("found %d stacks, want panic.stack and the exception event's", len(stacks))
+	}
+	for _, stack := range stacks {
+		frames := strings.Count(stack, "\n\t")
+		if frames == 0 || frames > 2 {
+			t.Errorf("stack has %d frames, want 1 to 2:\n%s", frames, stack)
+		}
+		if !strings.HasPrefix(stack, "github.com/d1agnoze/tracer.TestErrorStackDepth") {
+			t.Errorf("stack does not start at the panicking function:\n%s", stack)
+		}
+	}
+}
+
+func TestWithNoRecover(t *testing.T) {
+	rec := setup(t)
+	SetAutoOK(true)
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		s := New(context.Background(), "op", WithNoRecover())
+		defer s.End()
+		s.Attrs.StrKV("tenant", "acme")
+		panic("boom")
+	}()
+
+	if recovered != "boom" {
+		t.Fatalf("recovered %v, want the panic to propagate through End", recovered)
+	}
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	if got["tenant"].AsString() != "acme" {
+		t.Errorf("tenant = %q, want attributes extracted", got["tenant"].AsString())
+	}
+	if _, ok := got["panic.value"]; ok {
+		t.Error("End recorded the panic with recovery disabled")
+	}
+	if span.Status.Code != codes.Unset {
+		t.Errorf("status = %v, want Unset for a panicking span even with auto OK", span.Status.Code)
+	}
+}
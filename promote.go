@@ -0,0 +1,98 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// PromoteToResource lists attribute keys, such as service.version, that
+// belong on the resource rather than on individual spans. When a span
+// carries one of them, the exporter installed by Init removes it from the
+// span and exports the span under its resource extended with that value.
+// It only affects providers set up by Init after the call.
+func PromoteToResource(keys ...string) {
+	if cfg.promotedKeys == nil {
+		cfg.promotedKeys = map[attribute.Key]struct{}{}
+	}
+	for _, k := range keys {
+		cfg.promotedKeys[attribute.Key(k)] = struct{}{}
+	}
+}
+
+type promotingExporter struct {
+	sdktrace.SpanExporter
+	keys map[attribute.Key]struct{}
+
+	mu        sync.Mutex
+	resources map[attribute.Distinct]*resource.Resource
+}
+
+func newPromotingExporter(exp sdktrace.SpanExporter, keys map[attribute.Key]struct{}) *promotingExporter {
+	return &promotingExporter{
+		SpanExporter: exp,
+		keys:         keys,
+		resources:    map[attribute.Distinct]*resource.Resource{},
+	}
+}
+
+func (e *promotingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	out := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		out[i] = e.promote(span)
+	}
+	return e.SpanExporter.ExportSpans(ctx, out)
+}
+
+func (e *promotingExporter) promote(span sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	var kept, promoted []attribute.KeyValue
+	for _, kv := range span.Attributes() {
+		if _, ok := e.keys[kv.Key]; ok {
+			promoted = append(promoted, kv)
+		} else {
+			kept = append(kept, kv)
+		}
+	}
+	if len(promoted) == 0 {
+		return span
+	}
+
+	return promotedSpan{ReadOnlySpan: span, attrs: kept, res: e.resource(span.Resource(), promoted)}
+}
+
+// resource caches merged resources, since spans from one provider share
+// a base resource and usually the same promoted values.
+func (e *promotingExporter) resource(base *resource.Resource, promoted []attribute.KeyValue) *resource.Resource {
+	set := attribute.NewSet(append(base.Attributes(), promoted...)...)
+	key := set.Equivalent()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if res, ok := e.resources[key]; ok {
+		return res
+	}
+
+	res, err := resource.Merge(base, resource.NewSchemaless(promoted...))
+	if err != nil {
+		res = base
+	}
+	e.resources[key] = res
+	return res
+}
+
+type promotedSpan struct {
+	sdktrace.ReadOnlySpan
+	attrs []attribute.KeyValue
+	res   *resource.Resource
+}
+
+func (s promotedSpan) Attributes() []attribute.KeyValue {
+	return s.attrs
+}
+
+func (s promotedSpan) Resource() *resource.Resource {
+	return s.res
+}
@@ -0,0 +1,38 @@
+package tracer
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPromoteToResource(t *testing.T) {
+	setup(t)
+	PromoteToResource("service.version")
+	exp := tracetest.NewInMemoryExporter()
+	// Init wraps its exporter the same way.
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(newPromotingExporter(exp, maps.Clone(cfg.promotedKeys))))
+
+	s := New(context.Background(), "op", WithProvider(tp))
+	s.Attrs.StrKV("service.version", "1.4.2").StrKV("route", "/x")
+	s.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if _, ok := attrs["service.version"]; ok {
+		t.Error("promoted key is still on the span")
+	}
+	if attrs["route"].AsString() != "/x" {
+		t.Error("non-promoted attribute was removed")
+	}
+	if v, ok := spans[0].Resource.Set().Value(attribute.Key("service.version")); !ok || v.AsString() != "1.4.2" {
+		t.Errorf("resource service.version = %v, %v, want 1.4.2", v.Emit(), ok)
+	}
+}
@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// samplingPriorityKey is the tracestate entry carrying the priority.
+const samplingPriorityKey = "tracer-priority"
+
+// InjectWithBaggage copies the named attributes of the Span stored in ctx
+// (see StoreInContext) into baggage, then injects ctx into carrier with
+// the global propagator. Missing keys and keys that are not valid baggage
+// keys are skipped; string values are redacted first.
+func InjectWithBaggage(ctx context.Context, carrier propagation.TextMapCarrier, keys ...string) {
+	if s, ok := LoadFromContext(ctx); ok {
+		bag := baggage.FromContext(ctx)
+		for _, k := range keys {
+			v, ok := s.Attrs.lookup(k)
+			if !ok {
+				continue
+			}
+			m, err := baggage.NewMemberRaw(k, v)
+			if err != nil {
+				continue
+			}
+			if next, err := bag.SetMember(m); err == nil {
+				bag = next
+			}
+		}
+		ctx = baggage.ContextWithBaggage(ctx, bag)
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// SetSamplingPriority returns a copy of ctx whose span context carries p
+// in tracestate, so it is propagated on Inject and inherited by children
+// for downstream (e.g. tail) samplers to read. The span already in ctx
+// cannot change its own tracestate: in the returned ctx it is replaced by
+// a non-recording span with the same IDs, so record on the *Span wrapper
+// rather than on trace.SpanFromContext.
+func SetSamplingPriority(ctx context.Context, p int) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	ts, err := sc.TraceState().Insert(samplingPriorityKey, strconv.Itoa(p))
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, sc.WithTraceState(ts))
+}
+
+// SamplingPriority reads the priority set by SetSamplingPriority.
+func SamplingPriority(ctx context.Context) (int, bool) {
+	v := trace.SpanContextFromContext(ctx).TraceState().Get(samplingPriorityKey)
+	if v == "" {
+		return 0, false
+	}
+	p, err := strconv.Atoi(v)
+	return p, err == nil
+}
@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestInjectWithBaggage(t *testing.T) {
+	setup(t)
+
+	s := New(context.Background(), "upstream")
+	defer s.End()
+	s.Attrs.StrKV("tenant", "acme").StrKV("plan", "pro").StrKV("internal", "no")
+
+	carrier := propagation.MapCarrier{}
+	InjectWithBaggage(s.Ctx, carrier, "tenant", "plan", "missing")
+
+	downstream := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	bag := baggage.FromContext(downstream)
+	if v := bag.Member("tenant").Value(); v != "acme" {
+		t.Errorf("baggage tenant = %q, want acme", v)
+	}
+	if v := bag.Member("plan").Value(); v != "pro" {
+		t.Errorf("baggage plan = %q, want pro", v)
+	}
+	if bag.Len() != 2 {
+		t.Errorf("baggage has %d members, want 2", bag.Len())
+	}
+	d := New(downstream, "downstream")
+	defer d.End()
+	if d.TraceID() != s.TraceID() {
+		t.Error("downstream span is not in the upstream trace")
+	}
+}
+
+func TestSamplingPriorityRoundTrip(t *testing.T) {
+	setup(t)
+
+	s := New(context.Background(), "upstream")
+	defer s.End()
+	ctx := SetSamplingPriority(s.Ctx, 2)
+	if p, ok := SamplingPriority(ctx); !ok || p != 2 {
+		t.Fatalf("SamplingPriority = %d, %v, want 2", p, ok)
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	downstream := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	if p, ok := SamplingPriority(downstream); !ok || p != 2 {
+		t.Errorf("SamplingPriority after extract = %d, %v, want 2", p, ok)
+	}
+
+	child := New(downstream, "downstream")
+	defer child.End()
+	if p, ok := SamplingPriority(child.Ctx); !ok || p != 2 {
+		t.Errorf("child SamplingPriority = %d, %v, want 2", p, ok)
+	}
+	if _, ok := SamplingPriority(context.Background()); ok {
+		t.Error("SamplingPriority found a priority in an empty context")
+	}
+}
@@ -0,0 +1,86 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanStub is a snapshot of an ended span.
+type SpanStub = tracetest.SpanStub
+
+// TestRecorder keeps ended spans in memory for assertions in tests.
+type TestRecorder struct {
+	recorder *tracetest.SpanRecorder
+	provider *sdktrace.TracerProvider
+}
+
+// NewTestRecorder installs an in-memory tracer provider as the global
+// provider and returns a recorder for the spans it ends.
+func NewTestRecorder(opts ...sdktrace.TracerProviderOption) *TestRecorder {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(append(opts, sdktrace.WithSpanProcessor(rec))...)
+	otel.SetTracerProvider(tp)
+
+	return &TestRecorder{recorder: rec, provider: tp}
+}
+
+func (r *TestRecorder) Provider() *sdktrace.TracerProvider {
+	return r.provider
+}
+
+// Spans returns the ended spans ordered by start time, then by span ID, so
+// the order does not depend on when or from which goroutine they ended.
+// Each span's Events are ordered by timestamp, keeping insertion order
+// for equal timestamps.
+func (r *TestRecorder) Spans() []SpanStub {
+	spans := tracetest.SpanStubsFromReadOnlySpans(r.recorder.Ended())
+	for _, s := range spans {
+		slices.SortStableFunc(s.Events, func(a, b sdktrace.Event) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+	slices.SortStableFunc(spans, func(a, b SpanStub) int {
+		if c := a.StartTime.Compare(b.StartTime); c != 0 {
+			return c
+		}
+		aID, bID := a.SpanContext.SpanID(), b.SpanContext.SpanID()
+		return bytes.Compare(aID[:], bID[:])
+	})
+	return spans
+}
+
+// ReplaySpan creates and ends a span through the global provider that
+// reproduces stub: name, kind, parent, attributes, links, events, status
+// and timestamps. Span and trace IDs are newly generated.
+func ReplaySpan(stub SpanStub) {
+	ctx := context.Background()
+	if stub.Parent.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, stub.Parent)
+	}
+
+	links := make([]trace.Link, 0, len(stub.Links))
+	for _, l := range stub.Links {
+		links = append(links, trace.Link{SpanContext: l.SpanContext, Attributes: l.Attributes})
+	}
+
+	tracer := otel.Tracer(stub.InstrumentationScope.Name,
+		trace.WithInstrumentationVersion(stub.InstrumentationScope.Version))
+	_, span := tracer.Start(ctx, stub.Name,
+		trace.WithSpanKind(stub.SpanKind),
+		trace.WithTimestamp(stub.StartTime),
+		trace.WithAttributes(stub.Attributes...),
+		trace.WithLinks(links...),
+	)
+
+	for _, e := range stub.Events {
+		span.AddEvent(e.Name, trace.WithTimestamp(e.Time), trace.WithAttributes(e.Attributes...))
+	}
+	span.SetStatus(stub.Status.Code, stub.Status.Description)
+	span.End(trace.WithTimestamp(stub.EndTime))
+}
@@ -0,0 +1,117 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRecorderSpansOrder(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+
+	var spans []*Span
+	for i := range 6 {
+		if i%2 == 0 {
+			clock.Advance(time.Millisecond)
+		}
+		spans = append(spans, New(context.Background(), "op"))
+	}
+	var wg sync.WaitGroup
+	for _, s := range slices.Backward(spans) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.End()
+		}()
+	}
+	wg.Wait()
+
+	first := rec.Spans()
+	if len(first) != 6 {
+		t.Fatalf("got %d spans, want 6", len(first))
+	}
+	for i := 1; i < len(first); i++ {
+		a, b := first[i-1], first[i]
+		aID, bID := a.SpanContext.SpanID(), b.SpanContext.SpanID()
+		if c := a.StartTime.Compare(b.StartTime); c > 0 || c == 0 && bytes.Compare(aID[:], bID[:]) > 0 {
+			t.Errorf("spans %d and %d are out of order", i-1, i)
+		}
+	}
+	for range 3 {
+		again := rec.Spans()
+		for i := range again {
+			if again[i].SpanContext.SpanID() != first[i].SpanContext.SpanID() {
+				t.Fatalf("order changed between calls at %d", i)
+			}
+		}
+	}
+}
+
+func TestReplaySpan(t *testing.T) {
+	rec := setup(t)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stub := SpanStub{
+		Name:       "replayed",
+		SpanKind:   trace.SpanKindServer,
+		StartTime:  start,
+		EndTime:    start.Add(2 * time.Second),
+		Attributes: []attribute.KeyValue{attribute.String("route", "/orders")},
+		Events: []sdktrace.Event{{
+			Name:       "retry",
+			Time:       start.Add(time.Second),
+			Attributes: []attribute.KeyValue{attribute.Int("attempt", 2)},
+		}},
+		Status: sdktrace.Status{Code: codes.Error, Description: "boom"},
+	}
+
+	ReplaySpan(stub)
+
+	got := onlySpan(t, rec)
+	if got.Name != stub.Name || got.SpanKind != stub.SpanKind {
+		t.Errorf("name, kind = %q, %v, want %q, %v", got.Name, got.SpanKind, stub.Name, stub.SpanKind)
+	}
+	if !got.StartTime.Equal(stub.StartTime) || !got.EndTime.Equal(stub.EndTime) {
+		t.Errorf("times = %v-%v, want %v-%v", got.StartTime, got.EndTime, stub.StartTime, stub.EndTime)
+	}
+	if !slices.Equal(got.Attributes, stub.Attributes) {
+		t.Errorf("attributes = %v, want %v", got.Attributes, stub.Attributes)
+	}
+	if len(got.Events) != 1 || got.Events[0].Name != "retry" || !got.Events[0].Time.Equal(stub.Events[0].Time) {
+		t.Errorf("events = %v, want %v", got.Events, stub.Events)
+	}
+	if got.Status != stub.Status {
+		t.Errorf("status = %v, want %v", got.Status, stub.Status)
+	}
+}
+
+func TestRecorderEventsOrder(t *testing.T) {
+	rec := setup(t)
+	base := time.Now()
+
+	s := New(context.Background(), "op")
+	s.Event("late").Timestamp(base.Add(time.Hour)).Add()
+	s.Event("first").Add()
+	s.Event("second").Add()
+	s.Event("early").Timestamp(base.Add(-time.Hour)).Add()
+	s.Event("tied").Timestamp(base.Add(time.Hour)).Add()
+	s.Event("third").Add()
+	s.End()
+
+	var names []string
+	for _, e := range onlySpan(t, rec).Events {
+		names = append(names, e.Name)
+	}
+	if want := []string{"early", "first", "second", "third", "late", "tied"}; !slices.Equal(names, want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,116 @@
+package tracer
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redactor returns the value to record for a string attribute.
+type Redactor func(key, value string) string
+
+// SetRedactor sets the redactor Parse applies to string and string slice
+// values. A nil r disables it; value patterns still apply.
+func SetRedactor(r Redactor) {
+	cfg.redactor = r
+}
+
+// RedactKeys returns a Redactor that masks the values of the given keys,
+// compared case-insensitively.
+func RedactKeys(keys ...string) Redactor {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+
+	return func(key, value string) string {
+		if _, ok := set[strings.ToLower(key)]; ok {
+			return redactedValue
+		}
+		return value
+	}
+}
+
+// redactor returns the redactor that applies to a, or nil when redaction
+// was disabled for it.
+func (a *spanAttributes) redactor() Redactor {
+	if a.noRedact {
+		return nil
+	}
+	return withValuePatterns(cfg.redactor)
+}
+
+// SetLogRedactor sets the redactor ToMapRedacted applies for logs, which
+// may mask more, or less, than the trace redactor set by SetRedactor. A
+// nil r makes logs use the trace redactor.
+func SetLogRedactor(r Redactor) {
+	cfg.logRedactor = r
+}
+
+func (a *spanAttributes) logRedactor() Redactor {
+	if a.noRedact {
+		return nil
+	}
+	if cfg.logRedactor != nil {
+		return withValuePatterns(cfg.logRedactor)
+	}
+	return withValuePatterns(cfg.redactor)
+}
+
+type valuePattern struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// AddValueRedactPattern makes Parse replace matches of re in every string
+// value, whatever its key, with replacement, which may refer to
+// submatches as in regexp.ReplaceAllString. Patterns apply in the order
+// added, after the key-based redactor.
+func AddValueRedactPattern(re *regexp.Regexp, replacement string) {
+	cfg.valuePatterns = append(cfg.valuePatterns, valuePattern{re: re, replacement: replacement})
+}
+
+// withValuePatterns returns r followed by the value patterns, or r itself
+// when there are none.
+func withValuePatterns(r Redactor) Redactor {
+	patterns := cfg.valuePatterns
+	if len(patterns) == 0 {
+		return r
+	}
+
+	return func(key, value string) string {
+		if r != nil {
+			value = r(key, value)
+		}
+		for _, p := range patterns {
+			value = p.re.ReplaceAllString(value, p.replacement)
+		}
+		return value
+	}
+}
+
+func redactValue(r Redactor, key, value string) string {
+	if r == nil {
+		return value
+	}
+	return r(key, value)
+}
+
+func redactValues(r Redactor, key string, values []string) []string {
+	if r == nil {
+		return values
+	}
+
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = r(key, v)
+	}
+	return out
+}
+
+// DisableRedaction makes the span's own attributes skip the global
+// redactor, for spans that only handle data already known to be safe.
+func (s *Span) DisableRedaction() {
+	s.Attrs.noRedact = true
+}
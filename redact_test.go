@@ -0,0 +1,69 @@
+package tracer
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestDisableRedaction(t *testing.T) {
+	rec := setup(t)
+	SetRedactor(RedactKeys("email"))
+
+	redacted := New(context.Background(), "redacted")
+	redacted.Attrs.StrKV("email", "ada@example.com")
+	redacted.End()
+
+	raw := New(context.Background(), "raw")
+	raw.DisableRedaction()
+	raw.Attrs.StrKV("email", "ada@example.com")
+	raw.End()
+
+	if v := attrMap(spanNamed(t, rec, "redacted").Attributes)["email"].AsString(); v != redactedValue {
+		t.Errorf("email = %q, want it redacted", v)
+	}
+	if v := attrMap(spanNamed(t, rec, "raw").Attributes)["email"].AsString(); v != "ada@example.com" {
+		t.Errorf("email with redaction disabled = %q", v)
+	}
+}
+
+func TestToMapRedactedForLogs(t *testing.T) {
+	setup(t)
+	SetRedactor(RedactKeys("password"))
+	SetLogRedactor(RedactKeys("password", "email"))
+	attrs := NewAttrs().StrKV("email", "ada@example.com").StrKV("password", "hunter2")
+
+	logs := attrs.ToMapRedacted(true)
+	if logs["email"] != redactedValue || logs["password"] != redactedValue {
+		t.Errorf("log map = %v, want email and password masked", logs)
+	}
+	traces := attrs.ToMapRedacted(false)
+	if traces["email"] != "ada@example.com" || traces["password"] != redactedValue {
+		t.Errorf("trace map = %v, want only password masked", traces)
+	}
+}
+
+func TestAddValueRedactPattern(t *testing.T) {
+	rec := setup(t)
+	AddValueRedactPattern(regexp.MustCompile(`[\w.]+@[\w.]+`), "[EMAIL]")
+	AddValueRedactPattern(regexp.MustCompile(`\b(?:\d[ -]?){13,16}\b`), "[CARD]")
+
+	s := New(context.Background(), "checkout")
+	s.Attrs.StrKV("contact", "mail ada@example.com today").
+		StrKV("payment", "4111 1111 1111 1111").
+		StrKV("sku", "book-42")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	for key, want := range map[attribute.Key]string{
+		"contact": "mail [EMAIL] today",
+		"payment": "[CARD]",
+		"sku":     "book-42",
+	} {
+		if v := got[key].AsString(); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type forceSampleKey struct{}
+
+// WithForceSample marks ctx so RecommendedSampler keeps every span started
+// from it, e.g. when a request asks to be traced through a header. Spans
+// created with New carry the mark on to their children.
+func WithForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+func forceSampled(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceSampleKey{}).(bool)
+	return forced
+}
+
+// RecommendedSampler keeps spans from contexts marked with
+// WithForceSample, then spans matching a WithAttributeRule, follows the
+// parent's decision when there is a parent, and samples ratio of new root
+// traces by trace ID. Sampled spans carry sampling.reason naming the rule
+// that decided: forced, attribute, parent or ratio. Ratio-sampled root
+// spans also carry sampling.probability set to ratio so backends can
+// up-weight them.
+func RecommendedSampler(ratio float64, opts ...SamplerOption) sdktrace.Sampler {
+	s := recommendedSampler{ratio: ratio, root: sdktrace.TraceIDRatioBased(ratio)}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// SamplerOption configures RecommendedSampler.
+type SamplerOption func(*recommendedSampler)
+
+// WithAttributeRule keeps spans started with attribute key set to one of
+// values, compared in their string form, e.g. "true" for a bool. Only
+// attributes given at start are visible; see NewWithAttrs.
+func WithAttributeRule(key string, values ...string) SamplerOption {
+	return func(s *recommendedSampler) {
+		s.rules = append(s.rules, attributeRule{key: attribute.Key(key), values: values})
+	}
+}
+
+type attributeRule struct {
+	key    attribute.Key
+	values []string
+}
+
+func (r attributeRule) matches(attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if kv.Key == r.key && slices.Contains(r.values, kv.Value.Emit()) {
+			return true
+		}
+	}
+	return false
+}
+
+type recommendedSampler struct {
+	ratio float64
+	root  sdktrace.Sampler
+	rules []attributeRule
+}
+
+func (s recommendedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+
+	switch {
+	case forceSampled(p.ParentContext):
+		return sampled(psc, "forced")
+	case s.matchesRule(p.Attributes):
+		return sampled(psc, "attribute")
+	case psc.IsValid() && psc.IsSampled():
+		return sampled(psc, "parent")
+	case psc.IsValid():
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: psc.TraceState()}
+	default:
+		res := s.root.ShouldSample(p)
+		if res.Decision == sdktrace.RecordAndSample {
+			res.Attributes = append(res.Attributes,
+				attribute.String("sampling.reason", "ratio"),
+				attribute.Float64("sampling.probability", s.ratio))
+		}
+		return res
+	}
+}
+
+func (s recommendedSampler) matchesRule(attrs []attribute.KeyValue) bool {
+	for _, r := range s.rules {
+		if r.matches(attrs) {
+			return true
+		}
+	}
+	return false
+}
+
+func sampled(psc trace.SpanContext, reason string) sdktrace.SamplingResult {
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{attribute.String("sampling.reason", reason)},
+		Tracestate: psc.TraceState(),
+	}
+}
+
+func (s recommendedSampler) Description() string {
+	return fmt.Sprintf("RecommendedSampler{ratio:%g}", s.ratio)
+}
+
+type syntheticKey struct{}
+
+// MarkSynthetic flags the span as synthetic traffic, such as a health
+// check, with synthetic=true, and marks its Ctx so DropSynthetic drops
+// spans started from it. The span itself was sampled at start; to drop it
+// too, start it with the attribute, e.g. via NewWithAttrs.
+func (s *Span) MarkSynthetic() {
+	s.Attrs.BoolKV("synthetic", true)
+	if s.Ctx != nil {
+		s.Ctx = context.WithValue(s.Ctx, syntheticKey{}, true)
+	}
+}
+
+// DropSynthetic wraps base to drop spans started with synthetic=true or
+// from a context marked by MarkSynthetic, deferring to base otherwise.
+func DropSynthetic(base sdktrace.Sampler) sdktrace.Sampler {
+	return dropSynthetic{base: base}
+}
+
+type dropSynthetic struct {
+	base sdktrace.Sampler
+}
+
+func (s dropSynthetic) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	synthetic, _ := p.ParentContext.Value(syntheticKey{}).(bool)
+	if !synthetic {
+		synthetic = slices.Contains(p.Attributes, attribute.Bool("synthetic", true))
+	}
+	if synthetic {
+		psc := trace.SpanContextFromContext(p.ParentContext)
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: psc.TraceState()}
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s dropSynthetic) Description() string {
+	return fmt.Sprintf("DropSynthetic{%s}", s.base.Description())
+}
@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestWithForceSample(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(RecommendedSampler(0)))
+
+	dropped := New(context.Background(), "dropped")
+	dropped.End()
+	if dropped.Span.IsRecording() {
+		t.Error("span without the flag was sampled by a never-sampling ratio")
+	}
+
+	s := New(WithForceSample(context.Background()), "forced")
+	child := s.Child("forced-child")
+	child.End()
+	s.End()
+
+	if !s.Span.SpanContext().IsSampled() || !child.Span.SpanContext().IsSampled() {
+		t.Error("forced span or its child was not sampled")
+	}
+	if v := attrMap(spanNamed(t, rec, "forced").Attributes)["sampling.reason"].AsString(); v != "forced" {
+		t.Errorf("sampling.reason = %q, want forced", v)
+	}
+	if len(rec.Spans()) != 2 {
+		t.Errorf("got %d spans, want the forced span and its child", len(rec.Spans()))
+	}
+}
+
+func TestSamplingProbability(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(RecommendedSampler(0.25)))
+
+	for range 200 {
+		New(context.Background(), "root").End()
+	}
+
+	spans := rec.Spans()
+	if len(spans) == 0 || len(spans) == 200 {
+		t.Fatalf("sampled %d of 200 roots, want about a quarter", len(spans))
+	}
+	for _, s := range spans {
+		got := attrMap(s.Attributes)
+		if p := got["sampling.probability"].AsFloat64(); p != 0.25 {
+			t.Fatalf("sampling.probability = %v, want 0.25", p)
+		}
+		if got["sampling.reason"].AsString() != "ratio" {
+			t.Fatalf("sampling.reason = %q, want ratio", got["sampling.reason"].AsString())
+		}
+	}
+}
+
+func TestSamplingReason(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(RecommendedSampler(1, WithAttributeRule("tier", "gold"))))
+
+	New(WithForceSample(context.Background()), "forced").End()
+	NewWithAttrs(context.Background(), "attribute", NewAttrs().StrKV("tier", "gold")).End()
+	root := New(context.Background(), "ratio")
+	root.Child("parent").End()
+	root.End()
+
+	for _, name := range []string{"forced", "attribute", "ratio", "parent"} {
+		if v := attrMap(spanNamed(t, rec, name).Attributes)["sampling.reason"].AsString(); v != name {
+			t.Errorf("span %q sampling.reason = %q, want %q", name, v, name)
+		}
+	}
+}
+
+func TestNewSampled(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(RecommendedSampler(0, WithAttributeRule("tier", "gold"))))
+
+	NewSampled(context.Background(), "dropped", NewAttrs().StrKV("tier", "free")).End()
+	NewSampled(context.Background(), "kept", NewAttrs().StrKV("tier", "gold")).End()
+
+	span := onlySpan(t, rec)
+	if span.Name != "kept" {
+		t.Fatalf("exported %q, want the span matching the attribute rule", span.Name)
+	}
+	n := 0
+	for _, kv := range span.Attributes {
+		if kv.Key == "tier" {
+			n++
+		}
+	}
+	if n != 1 || attrMap(span.Attributes)["tier"].AsString() != "gold" {
+		t.Errorf("attributes = %v, want tier=gold exactly once", span.Attributes)
+	}
+}
+
+func TestDropSynthetic(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(DropSynthetic(sdktrace.AlwaysSample())))
+
+	probe := New(context.Background(), "probe")
+	probe.MarkSynthetic()
+	probe.Child("probe-child").End()
+	probe.End()
+	NewWithAttrs(context.Background(), "started-synthetic", NewAttrs().BoolKV("synthetic", true)).End()
+	New(context.Background(), "real").End()
+
+	// The probe itself was sampled before it was marked; only spans started
+	// after MarkSynthetic or with synthetic=true are dropped.
+	if n := len(rec.Spans()); n != 2 {
+		t.Fatalf("exported %d spans, want probe and real", n)
+	}
+	if !attrMap(spanNamed(t, rec, "probe").Attributes)["synthetic"].AsBool() {
+		t.Error("probe span lacks synthetic=true")
+	}
+	spanNamed(t, rec, "real")
+}
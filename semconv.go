@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// deprecatedKeys maps common deprecated semantic convention keys to their
+// replacements.
+var deprecatedKeys = map[attribute.Key]string{
+	"http.method":                           "http.request.method",
+	"http.status_code":                      "http.response.status_code",
+	"http.url":                              "url.full",
+	"http.target":                           "url.path and url.query",
+	"http.scheme":                           "url.scheme",
+	"http.user_agent":                       "user_agent.original",
+	"http.client_ip":                        "client.address",
+	"http.flavor":                           "network.protocol.version",
+	"net.peer.name":                         "server.address",
+	"net.peer.port":                         "server.port",
+	"net.host.name":                         "server.address",
+	"net.host.port":                         "server.port",
+	"net.sock.peer.addr":                    "network.peer.address",
+	"net.sock.peer.port":                    "network.peer.port",
+	"net.transport":                         "network.transport",
+	"messaging.kafka.destination.partition": "messaging.destination.partition.id",
+}
+
+var semConvWarned sync.Map
+
+// SetSemConvLint makes Extract warn, in debug mode, when a span uses a
+// deprecated semantic convention key such as http.method, naming the
+// replacement. Each key is reported once per process.
+func SetSemConvLint(enabled bool) {
+	cfg.semConvLint = enabled
+}
+
+func lintSemConv(kvs []attribute.KeyValue) {
+	if !cfg.debug || !cfg.semConvLint {
+		return
+	}
+
+	for _, kv := range kvs {
+		replacement, ok := deprecatedKeys[kv.Key]
+		if !ok {
+			continue
+		}
+		if _, warned := semConvWarned.LoadOrStore(kv.Key, true); !warned {
+			warn("attribute %q is deprecated, use %s", kv.Key, replacement)
+		}
+	}
+}
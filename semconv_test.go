@@ -0,0 +1,29 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSemConvLint(t *testing.T) {
+	setup(t)
+	var warnings []string
+	SetWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+	SetSemConvLint(true)
+	SetDebug(true)
+	semConvWarned.Delete(attribute.Key("http.method"))
+
+	for range 2 {
+		s := New(context.Background(), "op")
+		s.Attrs.StrKV("http.method", "GET").StrKV("http.request.method", "GET")
+		s.End()
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "http.method") ||
+		!strings.Contains(warnings[0], "http.request.method") {
+		t.Errorf("warnings = %q, want one for http.method naming its replacement", warnings)
+	}
+}
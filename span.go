@@ -2,34 +2,99 @@ package tracer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Span struct {
 	Ctx   context.Context
 	Span  trace.Span
 	Attrs spanAttributes
+
+	tracerName    string
+	provider      trace.TracerProvider
+	start         time.Time
+	errorCategory string
+	cacheHits     int
+	cacheMisses   int
+	softErrors    int
+	parent        *Span
+	panicked      atomic.Bool
+	childPanic    atomic.Pointer[childPanic]
+	name          string
+	status        codes.Code
+	statusDesc    string
+	errorCount    int
+	eventCount    atomic.Int64
+	eventCost     atomic.Int64
+	attrCost      int
+	recordedErrs  int
+	droppedErrs   int
+	droppedLinks  int
+	ended         atomic.Bool
+	ctxResolvers  []ctxResolver
+	noop          bool
+	sampled       bool
+	tracked       bool
+	borrowed      bool
+	discardEvents *spanEvents
+	noRecover     bool
+	links         []trace.Link
+	sloTarget     time.Duration
+	done          chan struct{}
+	counted       *selfMetrics
+}
+
+type ctxResolver struct {
+	key string
+	fn  func(context.Context) (string, bool)
 }
 
+// Attributes names the attribute set type for code outside the package,
+// e.g. when implementing AttributeParser or writing hooks that receive it.
+type Attributes = spanAttributes
+
 type spanAttributes struct {
 	Str   map[string]string
 	Bool  map[string]bool
 	Slice map[string][]string
 	Int   map[string]int
 	Float map[string]float64
+	Value map[string]attribute.Value
+
+	noRedact bool
+	frozen   bool
 }
 
+// spanEvents builds a single event. A builder is not safe for concurrent
+// use, but separate builders may be used from separate goroutines to add
+// events to the same Span concurrently. Attribute sets passed to
+// Attributes are copied before the inline setters (Str, Int, ...) write to
+// them, so one set can be shared by several events as long as the caller
+// does not mutate it while they are being built.
 type spanEvents struct {
 	msg       string
-	span      trace.Span
+	owner     *Span
 	attrs     *spanAttributes
-	timestamp *time.Time
+	ownAttrs  bool
+	timestamp time.Time
+	discard   bool
 }
 
 var (
@@ -45,25 +110,326 @@ var (
 type startOptions struct {
 	Kind       string
 	TracerName string
+
+	forcedSpanID trace.SpanID
+	provider     trace.TracerProvider
+
+	recordParentID bool
+	recordBudget   bool
+	attrs          *spanAttributes
+	links          []trace.Link
+	worker         string
+	component      string
+	queueDepth     func() int
+	noRecover      bool
+	retryOf        string
+	inheritDrop    bool
+	startTime      time.Time
+}
+
+// Option configures span creation in New and its variants.
+type Option func(*startOptions)
+
+// WithKind sets the span kind by name: internal, server, client, producer
+// or consumer. Unknown names fall back to internal.
+func WithKind(kind string) Option {
+	return func(o *startOptions) { o.Kind = kind }
+}
+
+func WithTracerName(name string) Option {
+	return func(o *startOptions) { o.TracerName = name }
+}
+
+// WithProvider creates the span from tp instead of the global provider.
+// Children started with Child use the same provider.
+func WithProvider(tp trace.TracerProvider) Option {
+	return func(o *startOptions) { o.provider = tp }
+}
+
+// WithRecordParentID attaches the parent's span ID as parent.span_id when
+// the incoming context carries a valid span.
+func WithRecordParentID() Option {
+	return func(o *startOptions) { o.recordParentID = true }
+}
+
+// WithWorker records the worker handling the span as worker.id.
+func WithWorker(id string) Option {
+	return func(o *startOptions) { o.worker = id }
+}
+
+// WithComponent records the component or module the span belongs to as
+// component, overriding the SetDefaultComponent default.
+func WithComponent(name string) Option {
+	return func(o *startOptions) { o.component = name }
+}
+
+// WithRecordRemainingBudget records the time left before the context
+// deadline when the span starts as remaining_budget_ms, to show where a
+// propagated deadline is spent. Contexts without a deadline record nothing.
+func WithRecordRemainingBudget() Option {
+	return func(o *startOptions) { o.recordBudget = true }
+}
+
+// WithQueueDepth records fn's result as queue.depth when the span starts,
+// so the queue length is read only for spans actually created.
+func WithQueueDepth(fn func() int) Option {
+	return func(o *startOptions) { o.queueDepth = fn }
+}
+
+// WithNoRecover makes End and its variants leave panics alone, for
+// services with their own panic handling: a panic passes through End
+// untouched while the span is still ended with its attributes. As End
+// cannot tell whether such a span is ending because of a panic, SetAutoOK
+// does not apply to it; its status stays Unset unless set explicitly.
+func WithNoRecover() Option {
+	return func(o *startOptions) { o.noRecover = true }
+}
+
+// WithRetryOf marks the span as a retry of the earlier attempt with the
+// given hex span ID, recording retry.of_span_id and, when the ID is valid
+// and ctx has a span context, linking to that attempt in the same trace.
+func WithRetryOf(previousSpanID string) Option {
+	return func(o *startOptions) { o.retryOf = previousSpanID }
+}
+
+func retryLink(ctx context.Context, spanID string) (trace.Link, bool) {
+	psc := trace.SpanContextFromContext(ctx)
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil || !psc.IsValid() {
+		return trace.Link{}, false
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: psc.TraceID(), SpanID: sid})
+	return trace.Link{SpanContext: sc}, true
+}
+
+func newStartOptions(base startOptions, opts []Option) startOptions {
+	for _, opt := range opts {
+		opt(&base)
+	}
+	return base
 }
 
 func NewAttrs() *spanAttributes {
 	return &spanAttributes{}
 }
 
-func New(ctx context.Context, spanName string, tracerArgs ...startOptions) *Span {
-	opt := startOptions{}
-	kind := trace.SpanKindInternal
+func New(ctx context.Context, spanName string, opts ...Option) *Span {
+	return start(ctx, spanName, newStartOptions(startOptions{}, opts))
+}
+
+// NewWithAttrs starts a span with attrs visible to the sampler and keeps
+// them in Attrs so they are also applied on End.
+func NewWithAttrs(ctx context.Context, spanName string, attrs *spanAttributes, opts ...Option) *Span {
+	opt := newStartOptions(startOptions{}, opts)
+	opt.attrs = attrs
+	return start(ctx, spanName, opt)
+}
 
-	if len(tracerArgs) > 0 {
-		opt = tracerArgs[0]
+// NewSampled is NewWithAttrs, named for use with attribute-based samplers
+// such as RecommendedSampler with WithAttributeRule. The attributes are
+// applied again on End under the same keys, which the SDK overwrites
+// rather than duplicates.
+func NewSampled(ctx context.Context, spanName string, attrs *spanAttributes, opts ...Option) *Span {
+	return NewWithAttrs(ctx, spanName, attrs, opts...)
+}
+
+// NewQueued starts a span for work that waited in a queue since
+// enqueuedAt, recording the wait as queue.wait_ms.
+func NewQueued(ctx context.Context, spanName string, enqueuedAt time.Time, opts ...Option) *Span {
+	s := start(ctx, spanName, newStartOptions(startOptions{}, opts))
+	s.Attrs.IntKV("queue.wait_ms", durationMs(s.start.Sub(enqueuedAt)))
+	return s
+}
+
+// NewTimeout starts a span whose Ctx times out after d, recording d as
+// timeout_ms. Call the returned cancel once the work is done, as with
+// context.WithTimeout.
+func NewTimeout(ctx context.Context, spanName string, d time.Duration, opts ...Option) (*Span, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	s := start(ctx, spanName, newStartOptions(startOptions{}, opts))
+	s.Attrs.IntKV("timeout_ms", durationMs(d))
+	return s, cancel
+}
+
+func start(ctx context.Context, spanName string, opt startOptions) *Span {
+	spanName = truncate(spanName, cfg.maxSpanNameLen)
+	if opt.TracerName == "" {
+		opt.TracerName = cfg.defaultTracerName
+	}
+	if opt.TracerName == "" && cfg.strictTracerName {
+		warn("span %q has no tracer name, returning a no-op span", spanName)
+		s := Noop()
+		s.Ctx, s.name = ctx, spanName
+		return s
+	}
+
+	startTime := opt.startTime
+	if startTime.IsZero() {
+		startTime = now()
+	}
+	if opt.retryOf != "" {
+		if link, ok := retryLink(ctx, opt.retryOf); ok {
+			opt.links = append(slices.Clip(opt.links), link)
+		}
+	}
+	droppedLinks := 0
+	if cfg.maxLinks > 0 && len(opt.links) > cfg.maxLinks {
+		droppedLinks = len(opt.links) - cfg.maxLinks
+		opt.links = opt.links[:cfg.maxLinks]
+	}
+	if len(opt.links) > 0 {
+		opt.links = slices.Clone(opt.links)
+		for i := range opt.links {
+			opt.links[i].Attributes = transformAttrs(opt.links[i].Attributes)
+		}
+	}
+	parent, _ := LoadFromContext(ctx)
+	parentSC := trace.SpanContextFromContext(ctx)
+
+	var span trace.Span
+	if opt.inheritDrop {
+		// Neither a sampler nor a span processor would see this span, so
+		// skip the tracer and building its start options.
+		ctx = droppedChildContext(ctx, opt.forcedSpanID)
+		span = trace.SpanFromContext(ctx)
+	} else {
+		kind := trace.SpanKindInternal
 		if spanKind, ok := kindMap[opt.Kind]; ok {
 			kind = spanKind
 		}
+		spanOpts := []trace.SpanStartOption{trace.WithSpanKind(kind), trace.WithTimestamp(startTime)}
+		if opt.attrs != nil {
+			spanOpts = append(spanOpts, trace.WithAttributes(transformAttrs(opt.attrs.Parse())...))
+		}
+		if len(opt.links) > 0 {
+			spanOpts = append(spanOpts, trace.WithLinks(opt.links...))
+		}
+		tp := opt.provider
+		if tp == nil {
+			tp = otel.GetTracerProvider()
+		}
+		ctx, span = tp.Tracer(opt.TracerName).Start(withForcedSpanID(ctx, opt.forcedSpanID), spanName, spanOpts...)
 	}
 
-	ctx, span := otel.Tracer(opt.TracerName).Start(ctx, spanName, trace.WithSpanKind(kind))
-	return &Span{Ctx: ctx, Span: span}
+	s := &Span{
+		Span:         span,
+		name:         spanName,
+		tracerName:   opt.TracerName,
+		provider:     opt.provider,
+		start:        startTime,
+		parent:       parent,
+		sampled:      span.SpanContext().IsSampled(),
+		links:        opt.links,
+		droppedLinks: droppedLinks,
+		noRecover:    opt.noRecover,
+		done:         make(chan struct{}),
+	}
+	s.Ctx = StoreInContext(ctx, s)
+	if !span.IsRecording() {
+		s.discardEvents = &spanEvents{owner: s, discard: true}
+	}
+	s.flushBufferedEvents(ctx)
+	if opt.attrs != nil {
+		s.Attrs = *opt.attrs.clone()
+	}
+
+	if opt.worker != "" {
+		s.SetWorker(opt.worker)
+	}
+	if opt.retryOf != "" {
+		s.Attrs.StrKV("retry.of_span_id", opt.retryOf)
+	}
+	if opt.component == "" {
+		opt.component = cfg.defaultComponent
+	}
+	if opt.component != "" {
+		s.Attrs.StrKV("component", opt.component)
+	}
+	if opt.queueDepth != nil {
+		s.QueueDepth(opt.queueDepth())
+	}
+	if opt.recordParentID && parentSC.IsValid() {
+		s.Attrs.StrKV("parent.span_id", parentSC.SpanID().String())
+	}
+	if deadline, ok := ctx.Deadline(); ok && opt.recordBudget {
+		s.Attrs.IntKV("remaining_budget_ms", durationMs(deadline.Sub(startTime)))
+	}
+	if cfg.trackActive {
+		s.tracked = true
+		trackActive(s, opt.attrs)
+	}
+	s.counted = cfg.selfMetrics
+	s.counted.spanStarted()
+	return s
+}
+
+// Noop returns a span that records and exports nothing, for code paths
+// that explicitly opt out of tracing. End and Extract do nothing and
+// children are no-op spans too, sharing the parent's Ctx.
+func Noop() *Span {
+	s := &Span{Ctx: context.Background(), Span: noop.Span{}, noop: true}
+	s.discardEvents = &spanEvents{owner: s, discard: true}
+	return s
+}
+
+// droppedChildContext returns ctx carrying a non-recording span context
+// for a child of the unsampled span in ctx: same trace ID, flags and
+// trace state, but its own span ID.
+func droppedChildContext(ctx context.Context, forced trace.SpanID) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	id := forcedIDGenerator{}.NewSpanID(withForcedSpanID(ctx, forced), sc.TraceID())
+	return trace.ContextWithSpanContext(ctx, sc.WithSpanID(id).WithRemote(false))
+}
+
+// Child starts a span under s. It uses the same tracer name and provider as
+// s unless overridden with WithTracerName or WithProvider. See
+// SetReuseParentSampling for children of spans that were not sampled.
+func (s *Span) Child(spanName string, opts ...Option) *Span {
+	if s.noop {
+		c := Noop()
+		c.Ctx, c.name = s.Ctx, spanName
+		return c
+	}
+	base := startOptions{TracerName: s.tracerName, provider: s.provider}
+	base.inheritDrop = cfg.reuseParentSampling && !s.sampled && !s.Span.IsRecording() && !forceSampled(s.Ctx)
+	return start(s.Ctx, spanName, newStartOptions(base, opts))
+}
+
+// SubTracer joins a base tracer name and a sub-component, e.g.
+// SubTracer("mylib", "db") is "mylib/db".
+func SubTracer(base, sub string) string {
+	if base == "" {
+		return sub
+	}
+	if sub == "" {
+		return base
+	}
+	return base + "/" + sub
+}
+
+// SetName renames the span, applying the same length limit as New.
+func (s *Span) SetName(name string) {
+	s.name = truncate(name, cfg.maxSpanNameLen)
+	s.Span.SetName(s.name)
+}
+
+// truncate cuts v to at most n bytes on a rune boundary and appends "...".
+// A limit <= 0 leaves v unchanged.
+func truncate(v string, n int) string {
+	if n <= 0 || len(v) <= n {
+		return v
+	}
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n] + "..."
+}
+
+// TracerName returns the tracer (instrumentation scope) name the span was
+// created with.
+func (s *Span) TracerName() string {
+	return s.tracerName
 }
 
 func (s *Span) TraceID() string {
@@ -74,8 +440,22 @@ func (s *Span) SpanID() string {
 	return s.Span.SpanContext().SpanID().String()
 }
 
+func (s *Span) TraceIDRaw() trace.TraceID {
+	return s.Span.SpanContext().TraceID()
+}
+
+func (s *Span) SpanIDRaw() trace.SpanID {
+	return s.Span.SpanContext().SpanID()
+}
+
+// Event starts building an event. For spans that were not recording when
+// they started, it returns a shared builder that drops everything, so
+// events cost no allocations.
 func (s *Span) Event(msg string) *spanEvents {
-	return &spanEvents{span: s.Span, msg: msg}
+	if s.discardEvents != nil {
+		return s.discardEvents
+	}
+	return &spanEvents{owner: s, msg: msg}
 }
 
 func (s *Span) AddLink(ctx context.Context, attrs ...spanAttributes) {
@@ -84,28 +464,321 @@ func (s *Span) AddLink(ctx context.Context, attrs ...spanAttributes) {
 		attr = attrs[0].Parse()
 	}
 
-	s.Span.AddLink(trace.LinkFromContext(ctx, attr...))
+	s.addLink(trace.LinkFromContext(ctx, attr...))
+}
+
+// AddLinkWithTime links the span in ctx and records how long ago it
+// happened as link.age_ms.
+func (s *Span) AddLinkWithTime(ctx context.Context, at time.Time) {
+	if s.addLink(trace.LinkFromContext(ctx)) {
+		s.Attrs.IntKV("link.age_ms", durationMs(now().Sub(at)))
+	}
+}
+
+// addLink adds link unless the span is at the SetMaxLinksPerSpan limit,
+// reporting whether it was added.
+func (s *Span) addLink(link trace.Link) bool {
+	if cfg.maxLinks > 0 && len(s.links) >= cfg.maxLinks {
+		s.droppedLinks++
+		return false
+	}
+
+	link.Attributes = transformAttrs(link.Attributes)
+	s.links = append(s.links, link)
+	s.Span.AddLink(link)
+	return true
+}
+
+// Link is a link to another span.
+type Link = trace.Link
+
+// Links returns the links given at start and added since, in order. otel
+// spans do not expose their links, so this is mainly for tests.
+func (s *Span) Links() []Link {
+	return slices.Clone(s.links)
+}
+
+// String renders the span as "name [trace_id/span_id] status k=v ..." for
+// debugging. Attribute values are redacted and sorted by key.
+func (s *Span) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s [%s/%s] %s", s.name, s.TraceID(), s.SpanID(), s.status)
+
+	for _, kv := range s.Attrs.ParseSorted() {
+		fmt.Fprintf(&b, " %s=%s", kv.Key, kv.Value.Emit())
+	}
+	return b.String()
+}
+
+// SetWorker records the worker handling the span as worker.id.
+func (s *Span) SetWorker(id string) {
+	s.Attrs.StrKV("worker.id", id)
+}
+
+// SecondaryKind records a second role of the span, one of the kinds
+// accepted by WithKind, as span.secondary_kind, e.g. "producer" on a
+// client span that also publishes a message. Unknown kinds are reported
+// through the warn func and not recorded.
+func (s *Span) SecondaryKind(kind string) {
+	if _, ok := kindMap[kind]; !ok {
+		warn("span %q: unknown secondary kind %q", s.name, kind)
+		return
+	}
+	s.Attrs.StrKV("span.secondary_kind", kind)
+}
+
+// IdempotencyKey records the operation's idempotency key as
+// idempotency.key. Like other string attributes it goes through the
+// redactor, so keys that double as secrets can be masked.
+func (s *Span) IdempotencyKey(key string) {
+	s.Attrs.StrKV("idempotency.key", key)
+}
+
+// Rollout records whether the request is in the gradual rollout name as
+// rollout.<name>.active and the rollout size as rollout.<name>.percentage,
+// clamped to [0, 100].
+func (s *Span) Rollout(name string, inRollout bool, pct float64) {
+	s.Attrs.BoolKV("rollout."+name+".active", inRollout).
+		FloatKV("rollout."+name+".percentage", min(max(pct, 0), 100))
+}
+
+// CountBy records per-value counts of label as int attributes
+// count.<label>.<value>, e.g. CountBy("item_type", counts) gives
+// count.item_type.book=3.
+func (s *Span) CountBy(label string, counts map[string]int) {
+	for value, n := range counts {
+		s.Attrs.IntKV("count."+label+"."+value, n)
+	}
+}
+
+// QueueDepth records the current length of the queue the span's work
+// came from as queue.depth.
+func (s *Span) QueueDepth(depth int) {
+	s.Attrs.IntKV("queue.depth", depth)
+}
+
+// Variant records which implementation ran, for A/B comparisons, in the
+// code.variant string slice. Repeated calls append.
+func (s *Span) Variant(name string) {
+	s.Attrs.SliceAppend("code.variant", name)
+}
+
+// SLO sets a latency target checked on End, which records slo.target_ms,
+// slo.breached and, when the span took longer, slo.over_by_ms.
+func (s *Span) SLO(target time.Duration) {
+	s.sloTarget = target
+}
+
+func (s *Span) checkSLO() {
+	if s.sloTarget <= 0 {
+		return
+	}
+
+	elapsed := now().Sub(s.start)
+	breached := elapsed > s.sloTarget
+	s.Attrs.IntKV("slo.target_ms", durationMs(s.sloTarget)).BoolKV("slo.breached", breached)
+	if breached {
+		s.Attrs.IntKV("slo.over_by_ms", durationMs(elapsed-s.sloTarget))
+	}
+}
+
+// IncrAttr adds delta to the int attribute k, starting from zero. The
+// final value is applied on End.
+func (s *Span) IncrAttr(k string, delta int) {
+	s.Attrs.IntKV(k, s.Attrs.Int[k]+delta)
+}
+
+// AttrFromContext registers a resolver Extract runs against s.Ctx, for
+// values that are only known by the end of the span. The attribute is set
+// only when fn returns true.
+func (s *Span) AttrFromContext(attrKey string, fn func(context.Context) (string, bool)) {
+	s.ctxResolvers = append(s.ctxResolvers, ctxResolver{key: attrKey, fn: fn})
+}
+
+// CopyAttrsFrom merges other's Attrs into s.Attrs, overwriting keys both
+// define. It is a no-op when either span is nil.
+func (s *Span) CopyAttrsFrom(other *Span) {
+	if s == nil || other == nil {
+		return
+	}
+	s.Attrs.merge(&other.Attrs)
 }
 
 // Span.Extract process all current attribute into otel Span instance
 func (s *Span) Extract() {
-	s.Span.SetAttributes(s.Attrs.Parse()...)
+	if s.noop {
+		return
+	}
+	for _, r := range s.ctxResolvers {
+		if v, ok := r.fn(s.Ctx); ok {
+			s.Attrs.StrKV(r.key, v)
+		}
+	}
+	if cfg.attrTrimmer != nil {
+		cfg.attrTrimmer(s, &s.Attrs)
+	}
+	kvs := transformAttrs(cfg.attrParser.Parse(&s.Attrs))
+	lintSemConv(kvs)
+	if cfg.extractSorted {
+		sortKVs(kvs)
+	}
+	if cfg.costHook != nil {
+		s.attrCost = kvBytes(kvs)
+	}
+	s.Span.SetAttributes(kvs...)
 }
 
+// End extracts Attrs and ends the span. Deferred, it also recovers a
+// panic and records it on the span; see Recover for how nested spans
+// share a panic.
 func (s *Span) End() {
-	if r := recover(); r != nil {
-		err := fmt.Errorf("recovered from panic: %v", r)
-		s.Span.RecordError(err, trace.WithStackTrace(true))
-		s.Error(err)
+	if s.noop {
+		return
+	}
+
+	var r any
+	if !s.noRecover {
+		r = recover()
+	}
+	s.end(r)
+}
+
+// EndStatus sets the status to code with msg and ends the span like End,
+// including when deferred. A recovered panic takes precedence: the span
+// ends as Error with the panic, not with code.
+func (s *Span) EndStatus(code codes.Code, msg string) {
+	if s.noop {
+		return
+	}
+
+	var r any
+	if !s.noRecover {
+		r = recover()
+	}
+	if r == nil && !s.Ended() {
+		if code == codes.Error {
+			s.errorCount++
+		}
+		s.setStatus(code, msg)
+	}
+	s.end(r)
+}
+
+// EndWithCtx returns a func to defer that ends s, first marking it as
+// failed if s.Ctx was canceled or timed out: defer tracer.EndWithCtx(s)().
+// The recorded error is context.Cause, so a cause given to
+// context.WithCancelCause is kept. Panics are recovered as in End.
+func EndWithCtx(s *Span) func() {
+	return func() {
+		if s.noop {
+			return
+		}
+		var r any
+		if !s.noRecover {
+			r = recover()
+		}
+		if r == nil && !s.Ended() && s.Ctx != nil && s.Ctx.Err() != nil {
+			s.Error(context.Cause(s.Ctx))
+		}
+		s.end(r)
+	}
+}
+
+// end finishes the span; r is the value recovered by the deferred caller.
+// Only the first call on a non-noop span does any work: later ones neither
+// re-run resolvers nor fire hooks again, though a recovered panic is still
+// re-raised when SetRepanic is on.
+func (s *Span) end(r any) {
+	if s.noop || s.ended.Swap(true) {
+		if r != nil && cfg.repanic {
+			panic(r)
+		}
+		return
+	}
+
+	if r != nil {
+		s.recordPanic(r)
+	} else if cfg.autoOK && !s.borrowed && !s.noRecover && s.status == codes.Unset && s.errorCount == 0 {
+		s.setStatus(codes.Ok, "")
+	}
+	s.flushCounters()
+	if s.borrowed {
+		// The otel span belongs to the code that started it, which also
+		// ends it; only add what was recorded through the wrapper.
+		s.Extract()
+		s.Attrs.frozen = true
+		close(s.done)
+	} else {
+		s.checkSLO()
+		s.Extract()
+		s.finish()
+	}
+
+	if r != nil && cfg.repanic {
+		panic(r)
+	}
+}
+
+func (s *Span) finish() {
+	s.Attrs.frozen = true
+	end := now()
+	s.endEvent(end)
+	if s.Span.IsRecording() {
+		s.Span.End(trace.WithTimestamp(end))
+	} else {
 		s.Span.End()
+	}
+	if s.done != nil {
+		close(s.done)
+	}
+	s.counted.spanEnded()
+	if s.tracked {
+		untrackActive(s)
+	}
+	s.reportOutcome(end)
+	s.reportCost()
+}
+
+// Reset clears the span for reuse, e.g. from a pool: Ctx and Span are
+// nil, Attrs maps are emptied but keep their capacity, and all internal
+// state such as the ended flag and counters starts over. It must only be
+// called after End.
+func (s *Span) Reset() {
+	attrs := s.Attrs
+	attrs.Clear()
+	attrs.noRedact, attrs.frozen = false, false
+
+	*s = Span{Attrs: attrs}
+}
+
+// Ended reports whether End has been called.
+func (s *Span) Ended() bool {
+	return s.ended.Load()
+}
 
-		// NOTE: add your custom panic handling logic here, e.g., logging
+// flushCounters moves counters kept on the Span into Attrs.
+func (s *Span) flushCounters() {
+	if s.cacheHits > 0 || s.cacheMisses > 0 {
+		s.Attrs.IntKV("cache.hits", s.cacheHits).IntKV("cache.misses", s.cacheMisses)
+	}
+	if s.droppedErrs > 0 {
+		s.Attrs.IntKV("recorded_errors_dropped", s.droppedErrs)
+	}
+	if s.droppedLinks > 0 {
+		s.Attrs.IntKV("dropped_links", s.droppedLinks)
+	}
+}
 
+func (s *Span) endEvent(end time.Time) {
+	if !cfg.autoEndEvent || s.start.IsZero() {
 		return
 	}
 
-	s.Extract()
-	s.Span.End()
+	s.Event("span.end").Timestamp(end).Int("duration_ms", durationMs(end.Sub(s.start))).Add()
+}
+
+func durationMs(d time.Duration) int {
+	return int(d.Milliseconds())
 }
 
 func (s *Span) OK(msg ...string) {
@@ -113,7 +786,7 @@ func (s *Span) OK(msg ...string) {
 	if len(msg) > 0 {
 		description = msg[0]
 	}
-	s.Span.SetStatus(codes.Ok, description)
+	s.setStatus(codes.Ok, description)
 }
 
 func (s *Span) SError(msg string) {
@@ -121,41 +794,343 @@ func (s *Span) SError(msg string) {
 		return
 	}
 
-	s.Span.SetStatus(codes.Error, msg)
+	s.errorCount++
+	s.setStatus(codes.Error, msg)
+	s.keepOnError()
 }
 
 func (s *Span) Error(err error, recordError ...bool) {
 	if err != nil {
 		if len(recordError) > 0 && recordError[0] {
-			s.Span.RecordError(err)
+			s.recordError(err)
 		}
 
-		s.Span.SetStatus(codes.Error, err.Error())
+		s.errorCount++
+		s.setStatus(codes.Error, err.Error())
+		s.classify(err)
+		s.keepOnError()
 		// NOTE: add your custom error handling logic here
 	}
 }
 
+// SetStatus sets the span status directly, with the same precedence and
+// description limit as OK and Error.
+func (s *Span) SetStatus(code codes.Code, description string) {
+	s.setStatus(code, description)
+}
+
+// setStatus tracks the status locally with the same precedence as the SDK:
+// Ok is final and Unset never overrides Error.
+func (s *Span) setStatus(code codes.Code, description string) {
+	if code < s.status {
+		return
+	}
+	if s.status == codes.Ok {
+		return
+	}
+
+	description = truncate(description, cfg.maxStatusDescLen)
+
+	s.status, s.statusDesc = code, description
+	s.Span.SetStatus(code, description)
+}
+
 func (a *spanAttributes) Parse() []attribute.KeyValue {
-	out := []attribute.KeyValue{}
-	for k, v := range a.Str {
-		out = append(out, attribute.String(k, v))
+	return a.parse(a.redactor())
+}
+
+func (a *spanAttributes) parse(r Redactor) []attribute.KeyValue {
+	n := a.len()
+	out := make([]attribute.KeyValue, 0, n)
+	out = a.appendStrings(out, r)
+	if n == len(a.Str) {
+		return applyByteBudget(out)
 	}
+
 	for k, v := range a.Bool {
 		out = append(out, attribute.Bool(k, v))
 	}
 	for k, v := range a.Int {
+		if cfg.skipZeroNumerics && v == 0 {
+			continue
+		}
 		out = append(out, attribute.Int(k, v))
 	}
 	for k, v := range a.Float {
+		if cfg.skipZeroNumerics && v == 0 {
+			continue
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			switch cfg.nonFiniteFloats {
+			case NonFiniteDrop:
+				out = append(out, attribute.Bool(k+".invalid", true))
+				continue
+			case NonFiniteString:
+				out = append(out, attribute.String(k, strconv.FormatFloat(v, 'g', -1, 64)))
+				continue
+			}
+		}
 		out = append(out, attribute.Float64(k, v))
 	}
 	for k, v := range a.Slice {
-		out = append(out, attribute.StringSlice(k, v))
+		if cfg.skipEmptyAttrs && len(v) == 0 {
+			continue
+		}
+		if cfg.dedupSliceAttrs {
+			v = dedup(v)
+		}
+		out = append(out, attribute.StringSlice(k, redactValues(r, k, v)))
+	}
+	for k, v := range a.Value {
+		out = append(out, attribute.KeyValue{Key: attribute.Key(k), Value: v})
+	}
+	return applyByteBudget(out)
+}
+
+// ParseStrings is Parse for sets holding only string attributes, the
+// common case, and ignores values of other types. Parse takes the same
+// path on its own when a holds only strings.
+func (a *spanAttributes) ParseStrings() []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(a.Str))
+	return applyByteBudget(a.appendStrings(out, a.redactor()))
+}
+
+func (a *spanAttributes) appendStrings(out []attribute.KeyValue, r Redactor) []attribute.KeyValue {
+	for k, v := range a.Str {
+		if cfg.skipEmptyAttrs && v == "" {
+			continue
+		}
+		out = append(out, attribute.String(k, redactValue(r, k, v)))
 	}
 	return out
 }
 
+// len returns the number of entries across all maps.
+func (a *spanAttributes) len() int {
+	return len(a.Str) + len(a.Bool) + len(a.Slice) + len(a.Int) + len(a.Float) + len(a.Value)
+}
+
+// ToMap returns the parsed attributes keyed by name, e.g. for structured
+// logging. Values are redacted as for traces.
+func (a *spanAttributes) ToMap() map[string]any {
+	return toMap(a.Parse())
+}
+
+// ToMapRedacted is ToMap with forLogs selecting the redactor set by
+// SetLogRedactor instead of the trace one. Without a log redactor, logs
+// get the trace redaction.
+func (a *spanAttributes) ToMapRedacted(forLogs bool) map[string]any {
+	if !forLogs {
+		return a.ToMap()
+	}
+	return toMap(a.parse(a.logRedactor()))
+}
+
+func toMap(kvs []attribute.KeyValue) map[string]any {
+	out := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		out[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return out
+}
+
+// ParseSorted is Parse with the result ordered by key.
+func (a *spanAttributes) ParseSorted() []attribute.KeyValue {
+	return sortKVs(a.Parse())
+}
+
+func sortKVs(kvs []attribute.KeyValue) []attribute.KeyValue {
+	slices.SortFunc(kvs, func(x, y attribute.KeyValue) int {
+		return strings.Compare(string(x.Key), string(y.Key))
+	})
+	return kvs
+}
+
+func applyByteBudget(kvs []attribute.KeyValue) []attribute.KeyValue {
+	budget := cfg.attrValueByteBudget
+	if budget <= 0 {
+		return kvs
+	}
+
+	total := 0
+	strs := []int{}
+	for i, kv := range kvs {
+		if kv.Value.Type() == attribute.STRING {
+			total += len(kv.Value.AsString())
+			strs = append(strs, i)
+		}
+	}
+	if total <= budget {
+		return kvs
+	}
+
+	slices.SortFunc(strs, func(i, j int) int {
+		if c := len(kvs[j].Value.AsString()) - len(kvs[i].Value.AsString()); c != 0 {
+			return c
+		}
+		return strings.Compare(string(kvs[i].Key), string(kvs[j].Key))
+	})
+
+	drop := map[int]bool{}
+	for _, i := range strs {
+		if total <= budget {
+			break
+		}
+		total -= len(kvs[i].Value.AsString())
+		drop[i] = true
+	}
+
+	out := make([]attribute.KeyValue, 0, len(kvs)-len(drop)+1)
+	for i, kv := range kvs {
+		if !drop[i] {
+			out = append(out, kv)
+		}
+	}
+	return append(out, attribute.Bool("attrs.truncated", true))
+}
+
+func dedup(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
+
+// lookup returns the value of k in string form, redacted like Parse.
+func (a *spanAttributes) lookup(k string) (string, bool) {
+	if v, ok := a.Str[k]; ok {
+		return redactValue(a.redactor(), k, v), true
+	}
+	if v, ok := a.Bool[k]; ok {
+		return strconv.FormatBool(v), true
+	}
+	if v, ok := a.Int[k]; ok {
+		return strconv.Itoa(v), true
+	}
+	if v, ok := a.Float[k]; ok {
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	}
+	if v, ok := a.Slice[k]; ok {
+		return strings.Join(redactValues(a.redactor(), k, v), ","), true
+	}
+	if v, ok := a.Value[k]; ok {
+		return v.Emit(), true
+	}
+	return "", false
+}
+
+// Clear empties every map in place, keeping their capacity, so one
+// builder can be reused across loop iterations. Finish with the previous
+// contents first: it must not run while a Parse of a is still underway
+// or while a is still attached to a span or event that has not emitted.
+func (a *spanAttributes) Clear() *spanAttributes {
+	clear(a.Str)
+	clear(a.Bool)
+	clear(a.Slice)
+	clear(a.Int)
+	clear(a.Float)
+	clear(a.Value)
+	return a
+}
+
+func (a *spanAttributes) clone() *spanAttributes {
+	out := NewAttrs()
+	out.noRedact = a.noRedact
+	out.merge(a)
+	return out
+}
+
+// merge copies every entry of b into a, overwriting existing keys.
+func (a *spanAttributes) merge(b *spanAttributes) {
+	for k, v := range b.Str {
+		a.StrKV(k, v)
+	}
+	for k, v := range b.Bool {
+		a.BoolKV(k, v)
+	}
+	for k, v := range b.Int {
+		a.IntKV(k, v)
+	}
+	for k, v := range b.Float {
+		a.FloatKV(k, v)
+	}
+	for k, v := range b.Slice {
+		a.SliceKV(k, append([]string(nil), v...))
+	}
+	for k, v := range b.Value {
+		a.ValueKV(k, v)
+	}
+}
+
+// AttrsEqual reports whether a and b hold the same keys and values,
+// treating nil and empty sets alike.
+func AttrsEqual(a, b *spanAttributes) bool {
+	return AttrsEqualExcept(a, b)
+}
+
+// AttrsEqualExcept is AttrsEqual ignoring the listed keys, for attributes
+// such as timestamps or IDs that differ between runs.
+func AttrsEqualExcept(a, b *spanAttributes, ignore ...string) bool {
+	if a == nil {
+		a = NewAttrs()
+	}
+	if b == nil {
+		b = NewAttrs()
+	}
+
+	skip := make(map[string]struct{}, len(ignore))
+	for _, k := range ignore {
+		skip[k] = struct{}{}
+	}
+
+	return equalExcept(a.Str, b.Str, skip, equal) &&
+		equalExcept(a.Bool, b.Bool, skip, equal) &&
+		equalExcept(a.Int, b.Int, skip, equal) &&
+		equalExcept(a.Float, b.Float, skip, equal) &&
+		equalExcept(a.Slice, b.Slice, skip, slices.Equal) &&
+		equalExcept(a.Value, b.Value, skip, equal)
+}
+
+func equal[V comparable](x, y V) bool {
+	return x == y
+}
+
+func equalExcept[V any](a, b map[string]V, skip map[string]struct{}, eq func(V, V) bool) bool {
+	for k, av := range a {
+		if _, ok := skip[k]; ok {
+			continue
+		}
+		if bv, ok := b[k]; !ok || !eq(av, bv) {
+			return false
+		}
+	}
+	for k := range b {
+		if _, ok := skip[k]; ok {
+			continue
+		}
+		if _, ok := a[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFrozen reports, in debug mode, writes to the attributes of a span
+// that has already ended, which would otherwise be lost silently.
+func (a *spanAttributes) checkFrozen(k string) {
+	if cfg.debug && a.frozen {
+		warn("attribute %q set after End", k)
+	}
+}
+
 func (a *spanAttributes) StrKV(k string, v string) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Str == nil {
 		a.Str = map[string]string{}
 	}
@@ -164,6 +1139,7 @@ func (a *spanAttributes) StrKV(k string, v string) *spanAttributes {
 }
 
 func (a *spanAttributes) BoolKV(k string, v bool) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Bool == nil {
 		a.Bool = map[string]bool{}
 	}
@@ -172,6 +1148,7 @@ func (a *spanAttributes) BoolKV(k string, v bool) *spanAttributes {
 }
 
 func (a *spanAttributes) IntKV(k string, v int) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Int == nil {
 		a.Int = map[string]int{}
 	}
@@ -180,6 +1157,7 @@ func (a *spanAttributes) IntKV(k string, v int) *spanAttributes {
 }
 
 func (a *spanAttributes) FloatKV(k string, v float64) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Float == nil {
 		a.Float = map[string]float64{}
 	}
@@ -188,6 +1166,7 @@ func (a *spanAttributes) FloatKV(k string, v float64) *spanAttributes {
 }
 
 func (a *spanAttributes) SliceKV(k string, v []string) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Slice == nil {
 		a.Slice = map[string][]string{}
 	}
@@ -195,7 +1174,42 @@ func (a *spanAttributes) SliceKV(k string, v []string) *spanAttributes {
 	return a
 }
 
+// SliceAppend appends vs to the string slice k, creating it if absent.
+// The stored slice is never shared with one passed to SliceKV.
+func (a *spanAttributes) SliceAppend(k string, vs ...string) *spanAttributes {
+	return a.SliceKV(k, append(slices.Clip(a.Slice[k]), vs...))
+}
+
+// RatioKV records v clamped to [0, 1]. Out-of-range input, often a
+// percentage passed as 0-100, also sets <k>.out_of_range=true.
+func (a *spanAttributes) RatioKV(k string, v float64) *spanAttributes {
+	if v < 0 || v > 1 {
+		a.BoolKV(k+".out_of_range", true)
+		v = min(max(v, 0), 1)
+	}
+	return a.FloatKV(k, v)
+}
+
+// EpochMillisKV records ms as an int64 and, as <k>.iso, the same instant
+// in RFC 3339 UTC with millisecond precision.
+func (a *spanAttributes) EpochMillisKV(k string, ms int64) *spanAttributes {
+	a.ValueKV(k, attribute.Int64Value(ms))
+	return a.StrKV(k+".iso", time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+}
+
+// ValueKV stores an otel attribute.Value as is, for code that already
+// builds otel values. It is not redacted.
+func (a *spanAttributes) ValueKV(k string, v attribute.Value) *spanAttributes {
+	a.checkFrozen(k)
+	if a.Value == nil {
+		a.Value = map[string]attribute.Value{}
+	}
+	a.Value[k] = v
+	return a
+}
+
 func (a *spanAttributes) ErrorKV(k string, v error) *spanAttributes {
+	a.checkFrozen(k)
 	if a.Str == nil {
 		a.Str = map[string]string{}
 	}
@@ -209,23 +1223,153 @@ func (a *spanAttributes) ErrorKV(k string, v error) *spanAttributes {
 	return a
 }
 
+// AnySliceKV records a slice of mixed types, which otel slices cannot
+// hold, as one string attribute containing its JSON encoding, e.g.
+// []any{1, "x", true} becomes [1,"x",true]. The string is subject to
+// redaction and SetAttrValueByteBudget like any other. Values that cannot
+// be encoded are reported through the warn func and not recorded.
+func (a *spanAttributes) AnySliceKV(k string, v []any) *spanAttributes {
+	b, err := json.Marshal(v)
+	if err != nil {
+		warn("attribute %q: %v", k, err)
+		return a
+	}
+	return a.StrKV(k, string(b))
+}
+
+// IPKV records ip in canonical form plus <k>.family (v4 or v6). A nil ip
+// is recorded as "<nil>" without a family.
+func (a *spanAttributes) IPKV(k string, ip net.IP) *spanAttributes {
+	if ip == nil {
+		return a.StrKV(k, "<nil>")
+	}
+
+	a.StrKV(k, ip.String())
+	switch {
+	case ip.To4() != nil:
+		a.StrKV(k+".family", "v4")
+	case ip.To16() != nil:
+		a.StrKV(k+".family", "v6")
+	}
+	return a
+}
+
+// UserIDKV records userID as the hex SHA-256 of the salt set by
+// SetHashSalt followed by the ID, so spans from the same user correlate
+// without exposing the raw ID.
+func (a *spanAttributes) UserIDKV(k, userID string) *spanAttributes {
+	sum := sha256.Sum256([]byte(cfg.hashSalt + userID))
+	return a.StrKV(k, hex.EncodeToString(sum[:]))
+}
+
 func (e *spanEvents) Timestamp(input time.Time) *spanEvents {
-	e.timestamp = &input
+	if !e.discard {
+		e.timestamp = input
+	}
 	return e
 }
 
+// Offset sets the event timestamp to d after the span started.
+func (e *spanEvents) Offset(d time.Duration) *spanEvents {
+	return e.Timestamp(e.owner.start.Add(d))
+}
+
 func (e *spanEvents) Attributes(input *spanAttributes) *spanEvents {
+	if e.discard {
+		return e
+	}
 	e.attrs = input
+	e.ownAttrs = false
 	return e
 }
 
+func (e *spanEvents) Str(k string, v string) *spanEvents {
+	if !e.discard {
+		e.attributes().StrKV(k, v)
+	}
+	return e
+}
+
+func (e *spanEvents) Bool(k string, v bool) *spanEvents {
+	if !e.discard {
+		e.attributes().BoolKV(k, v)
+	}
+	return e
+}
+
+func (e *spanEvents) Int(k string, v int) *spanEvents {
+	if !e.discard {
+		e.attributes().IntKV(k, v)
+	}
+	return e
+}
+
+func (e *spanEvents) Float(k string, v float64) *spanEvents {
+	if !e.discard {
+		e.attributes().FloatKV(k, v)
+	}
+	return e
+}
+
+func (e *spanEvents) Slice(k string, v []string) *spanEvents {
+	if !e.discard {
+		e.attributes().SliceKV(k, v)
+	}
+	return e
+}
+
+func (e *spanEvents) Err(k string, v error) *spanEvents {
+	if !e.discard {
+		e.attributes().ErrorKV(k, v)
+	}
+	return e
+}
+
+// attributes returns an attribute set owned by this builder, copying a set
+// shared through Attributes before the first inline write.
+func (e *spanEvents) attributes() *spanAttributes {
+	switch {
+	case e.attrs == nil:
+		e.attrs = NewAttrs()
+	case !e.ownAttrs:
+		e.attrs = e.attrs.clone()
+	}
+	e.ownAttrs = true
+	return e.attrs
+}
+
+// Add adds the event to the span. On a span that is not recording, such
+// as an unsampled one, it only counts the event and skips parsing its
+// attributes.
 func (e *spanEvents) Add() {
+	if e.discard || !e.owner.Span.IsRecording() {
+		e.owner.eventCount.Add(1)
+		return
+	}
+	name := cfg.eventNameNormalizer(e.msg)
+	if !keepEvent(name) {
+		return
+	}
+
 	opts := []trace.EventOption{}
-	if e.timestamp != nil {
-		opts = append(opts, trace.WithTimestamp(*e.timestamp))
+	if !e.timestamp.IsZero() {
+		opts = append(opts, trace.WithTimestamp(e.timestamp))
 	}
 	if e.attrs != nil {
-		opts = append(opts, trace.WithAttributes(e.attrs.Parse()...))
+		opts = append(opts, trace.WithAttributes(transformAttrs(cfg.attrParser.Parse(e.attrs))...))
 	}
-	e.span.AddEvent(e.msg, opts...)
+	e.owner.addNormalizedEvent(name, opts...)
+}
+
+// addEvent is the single entry point for events so every helper gets the
+// same name handling and counting.
+func (s *Span) addEvent(msg string, opts ...trace.EventOption) {
+	s.addNormalizedEvent(cfg.eventNameNormalizer(msg), opts...)
+}
+
+// addNormalizedEvent is addEvent for a name already normalized.
+func (s *Span) addNormalizedEvent(name string, opts ...trace.EventOption) {
+	s.eventCount.Add(1)
+	s.countEventCost(name, opts)
+	s.Span.AddEvent(name, opts...)
 }
@@ -0,0 +1,1333 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEventNameNormalizer(t *testing.T) {
+	rec := setup(t)
+	trailingID := regexp.MustCompile(`[.:-]\d+$`)
+	SetEventNameNormalizer(func(name string) string { return trailingID.ReplaceAllString(name, "") })
+
+	s := New(context.Background(), "op")
+	s.Event("order.created:8812").Add()
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 1 || events[0].Name != "order.created" {
+		t.Fatalf("events = %v, want one named order.created", events)
+	}
+}
+
+func TestConcurrentEvents(t *testing.T) {
+	rec := setup(t)
+	shared := NewAttrs().StrKV("shared", "yes")
+
+	s := New(context.Background(), "op")
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				s.Event("work").Attributes(shared).Int("worker", i).Int("item", j).Add()
+			}
+		}()
+	}
+	wg.Wait()
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 80 {
+		t.Fatalf("got %d events, want 80", len(events))
+	}
+	if shared.len() != 1 {
+		t.Errorf("shared attributes were modified: %v", shared.ToMap())
+	}
+}
+
+func TestSkipEmptyAttrs(t *testing.T) {
+	setup(t)
+	SetSkipEmptyAttrs(true)
+	attrs := func() *spanAttributes {
+		return NewAttrs().StrKV("empty", "").SliceKV("none", []string{}).IntKV("zero", 0).StrKV("name", "x")
+	}
+
+	got := attrMap(attrs().Parse())
+	if _, ok := got["empty"]; ok {
+		t.Error("empty string was not skipped")
+	}
+	if _, ok := got["none"]; ok {
+		t.Error("empty slice was not skipped")
+	}
+	if _, ok := got["zero"]; !ok {
+		t.Error("zero int was skipped without SetSkipZeroNumericAttrs")
+	}
+	if _, ok := got["name"]; !ok {
+		t.Error("non-empty string was skipped")
+	}
+
+	SetSkipZeroNumericAttrs(true)
+	if _, ok := attrMap(attrs().Parse())["zero"]; ok {
+		t.Error("zero int was not skipped with SetSkipZeroNumericAttrs")
+	}
+}
+
+// recordingSampler samples everything and keeps the attributes each span
+// started with.
+type recordingSampler struct {
+	mu    sync.Mutex
+	attrs [][]attribute.KeyValue
+}
+
+func (r *recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	r.mu.Lock()
+	r.attrs = append(r.attrs, p.Attributes)
+	r.mu.Unlock()
+	return sdktrace.SamplingResult{Decision: sdktrace.RecordAndSample}
+}
+
+func (r *recordingSampler) Description() string { return "recordingSampler" }
+
+func TestNewWithAttrs(t *testing.T) {
+	sampler := &recordingSampler{}
+	rec := setup(t, sdktrace.WithSampler(sampler))
+
+	s := NewWithAttrs(context.Background(), "op", NewAttrs().StrKV("tenant", "acme"))
+	if v, ok := s.Attrs.lookup("tenant"); !ok || v != "acme" {
+		t.Errorf("s.Attrs tenant = %q, %v, want acme", v, ok)
+	}
+	s.End()
+
+	if len(sampler.attrs) != 1 || attrMap(sampler.attrs[0])["tenant"].AsString() != "acme" {
+		t.Errorf("sampler saw %v, want tenant=acme", sampler.attrs)
+	}
+	if v := attrMap(onlySpan(t, rec).Attributes)["tenant"].AsString(); v != "acme" {
+		t.Errorf("ended span tenant = %q, want acme", v)
+	}
+}
+
+func TestAutoEndEvent(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+	SetAutoEndEvent(true)
+
+	s := New(context.Background(), "op")
+	clock.Advance(1500 * time.Millisecond)
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 1 || events[0].Name != "span.end" {
+		t.Fatalf("events = %v, want one span.end", events)
+	}
+	if d := attrMap(events[0].Attributes)["duration_ms"].AsInt64(); d != 1500 {
+		t.Errorf("duration_ms = %d, want 1500", d)
+	}
+}
+
+func TestAttrsEqual(t *testing.T) {
+	base := func() *spanAttributes {
+		return NewAttrs().StrKV("a", "x").IntKV("n", 1).SliceKV("tags", []string{"p", "q"})
+	}
+	// Same content added in a different order.
+	same := NewAttrs().SliceKV("tags", []string{"p", "q"}).IntKV("n", 1).StrKV("a", "x")
+
+	if !AttrsEqual(base(), same) {
+		t.Error("AttrsEqual = false for equal sets")
+	}
+	if AttrsEqual(base(), base().IntKV("n", 2)) {
+		t.Error("AttrsEqual = true for a differing value")
+	}
+	if AttrsEqual(base(), base().SliceKV("tags", []string{"q", "p"})) {
+		t.Error("AttrsEqual = true for reordered slice elements")
+	}
+	if AttrsEqual(base(), NewAttrs().StrKV("a", "x").IntKV("n", 1)) {
+		t.Error("AttrsEqual = true with a missing key")
+	}
+}
+
+func TestNewQueued(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+
+	enqueued := clock.Now()
+	clock.Advance(250 * time.Millisecond)
+	s := NewQueued(context.Background(), "job", enqueued)
+	s.End()
+
+	if v := attrMap(onlySpan(t, rec).Attributes)["queue.wait_ms"].AsInt64(); v != 250 {
+		t.Errorf("queue.wait_ms = %d, want 250", v)
+	}
+}
+
+func TestChildInheritsTracerName(t *testing.T) {
+	rec := setup(t)
+
+	parent := New(context.Background(), "parent", WithTracerName("billing"))
+	child := parent.Child("child")
+	other := parent.Child("other", WithTracerName("ledger"))
+	if child.TracerName() != "billing" {
+		t.Errorf("child tracer name = %q, want billing", child.TracerName())
+	}
+	other.End()
+	child.End()
+	parent.End()
+
+	if name := spanNamed(t, rec, "child").InstrumentationScope.Name; name != "billing" {
+		t.Errorf("child scope = %q, want billing", name)
+	}
+	if name := spanNamed(t, rec, "other").InstrumentationScope.Name; name != "ledger" {
+		t.Errorf("overridden child scope = %q, want ledger", name)
+	}
+}
+
+func TestDedupSliceAttrs(t *testing.T) {
+	setup(t)
+	attrs := NewAttrs().SliceKV("tags", []string{"b", "a", "b", "c", "a"})
+
+	if got := attrMap(attrs.Parse())["tags"].AsStringSlice(); len(got) != 5 {
+		t.Errorf("tags without dedup = %v, want all 5 elements", got)
+	}
+	SetDedupSliceAttrs(true)
+	got := attrMap(attrs.Parse())["tags"].AsStringSlice()
+	if want := []string{"b", "a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("tags = %v, want %v", got, want)
+	}
+}
+
+func TestIPKV(t *testing.T) {
+	got := attrMap(NewAttrs().
+		IPKV("v4", net.ParseIP("192.0.2.1")).
+		IPKV("v6", net.ParseIP("2001:db8::1")).
+		IPKV("none", nil).
+		Parse())
+
+	for key, want := range map[attribute.Key]string{
+		"v4": "192.0.2.1", "v4.family": "v4",
+		"v6": "2001:db8::1", "v6.family": "v6",
+		"none": "<nil>",
+	} {
+		if v := got[key].AsString(); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	if _, ok := got["none.family"]; ok {
+		t.Error("nil IP has a family")
+	}
+}
+
+func TestValueKV(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.Attrs.ValueKV("bytes.total", attribute.Int64Value(1<<40))
+	s.End()
+
+	v := attrMap(onlySpan(t, rec).Attributes)["bytes.total"]
+	if v.Type() != attribute.INT64 || v.AsInt64() != 1<<40 {
+		t.Errorf("bytes.total = %v (%v), want int64 %d", v.Emit(), v.Type(), int64(1<<40))
+	}
+}
+
+func TestAttrValueByteBudget(t *testing.T) {
+	setup(t)
+	SetAttrValueByteBudget(20)
+
+	kvs := NewAttrs().
+		StrKV("long", strings.Repeat("x", 15)).
+		StrKV("medium", strings.Repeat("y", 8)).
+		StrKV("short", "zz").
+		IntKV("count", 3).
+		Parse()
+	got := attrMap(kvs)
+
+	if !got["attrs.truncated"].AsBool() {
+		t.Error("attrs.truncated is not set")
+	}
+	if _, ok := got["long"]; ok {
+		t.Error("the longest value was kept")
+	}
+	total := 0
+	for _, kv := range kvs {
+		if kv.Value.Type() == attribute.STRING {
+			total += len(kv.Value.AsString())
+		}
+	}
+	if total > 20 {
+		t.Errorf("emitted %d string bytes, want at most 20", total)
+	}
+	if _, ok := got["count"]; !ok {
+		t.Error("non-string attribute was dropped")
+	}
+}
+
+func TestRawIDs(t *testing.T) {
+	setup(t)
+
+	s := New(context.Background(), "op")
+	defer s.End()
+	if s.TraceIDRaw().String() != s.TraceID() || !s.TraceIDRaw().IsValid() {
+		t.Errorf("TraceIDRaw = %s, TraceID = %s", s.TraceIDRaw(), s.TraceID())
+	}
+	if s.SpanIDRaw().String() != s.SpanID() || !s.SpanIDRaw().IsValid() {
+		t.Errorf("SpanIDRaw = %s, SpanID = %s", s.SpanIDRaw(), s.SpanID())
+	}
+}
+
+// recordOnlySampler records every span but samples none.
+type recordOnlySampler struct{}
+
+func (recordOnlySampler) ShouldSample(sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return sdktrace.SamplingResult{Decision: sdktrace.RecordOnly}
+}
+
+func (recordOnlySampler) Description() string { return "recordOnlySampler" }
+
+func TestAttrTrimmer(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(recordOnlySampler{}))
+	SetAttrTrimmer(func(s *Span, attrs *spanAttributes) {
+		if !s.Span.SpanContext().IsSampled() {
+			delete(attrs.Str, "debug.payload")
+		}
+	})
+
+	s := New(context.Background(), "op")
+	s.Attrs.StrKV("debug.payload", "{...}").StrKV("user", "u1")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if _, ok := got["debug.payload"]; ok {
+		t.Error("trimmer did not drop debug.payload")
+	}
+	if got["user"].AsString() != "u1" {
+		t.Error("trimmer dropped an unrelated attribute")
+	}
+}
+
+func TestRatioKV(t *testing.T) {
+	got := attrMap(NewAttrs().RatioKV("ok", 0.25).RatioKV("neg", -0.5).RatioKV("pct", 85).Parse())
+
+	for key, want := range map[attribute.Key]float64{"ok": 0.25, "neg": 0, "pct": 1} {
+		if v := got[key].AsFloat64(); v != want {
+			t.Errorf("%s = %v, want %v", key, v, want)
+		}
+	}
+	if _, ok := got["ok.out_of_range"]; ok {
+		t.Error("in-range value marked out_of_range")
+	}
+	if !got["neg.out_of_range"].AsBool() || !got["pct.out_of_range"].AsBool() {
+		t.Error("out-of-range values are not marked")
+	}
+}
+
+func TestWithProvider(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	global := otel.GetTracerProvider()
+
+	s := New(context.Background(), "isolated", WithProvider(tp))
+	s.Child("child").End()
+	s.End()
+
+	if len(rec.Ended()) != 2 {
+		t.Fatalf("provider recorded %d spans, want 2", len(rec.Ended()))
+	}
+	if otel.GetTracerProvider() != global {
+		t.Error("WithProvider changed the global provider")
+	}
+}
+
+func TestSubTracer(t *testing.T) {
+	rec := setup(t)
+	name := SubTracer("mylib", "db")
+	if name != "mylib/db" {
+		t.Fatalf("SubTracer = %q, want mylib/db", name)
+	}
+	if SubTracer("", "db") != "db" || SubTracer("mylib", "") != "mylib" {
+		t.Error("SubTracer with an empty part added a separator")
+	}
+
+	s := New(context.Background(), "query", WithTracerName(name))
+	if s.TracerName() != name {
+		t.Errorf("TracerName = %q, want %q", s.TracerName(), name)
+	}
+	s.End()
+
+	if scope := onlySpan(t, rec).InstrumentationScope.Name; scope != name {
+		t.Errorf("scope = %q, want %q", scope, name)
+	}
+}
+
+func TestWithRecordParentID(t *testing.T) {
+	rec := setup(t)
+
+	parent := New(context.Background(), "parent")
+	child := parent.Child("child", WithRecordParentID())
+	child.End()
+	parent.End()
+
+	if v := attrMap(spanNamed(t, rec, "child").Attributes)["parent.span_id"].AsString(); v != parent.SpanID() {
+		t.Errorf("parent.span_id = %q, want %q", v, parent.SpanID())
+	}
+	root := New(context.Background(), "root", WithRecordParentID())
+	root.End()
+	if _, ok := attrMap(spanNamed(t, rec, "root").Attributes)["parent.span_id"]; ok {
+		t.Error("root span has parent.span_id")
+	}
+}
+
+// upperParser is an AttributeParser that uppercases keys.
+type upperParser struct{}
+
+func (upperParser) Parse(a *spanAttributes) []attribute.KeyValue {
+	kvs := a.Parse()
+	for i, kv := range kvs {
+		kvs[i].Key = attribute.Key(strings.ToUpper(string(kv.Key)))
+	}
+	return kvs
+}
+
+func TestSetAttributeParser(t *testing.T) {
+	rec := setup(t)
+	SetAttributeParser(upperParser{})
+
+	s := New(context.Background(), "op")
+	s.Attrs.StrKV("user.id", "u1")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if got["USER.ID"].AsString() != "u1" {
+		t.Errorf("attributes = %v, want USER.ID=u1", got)
+	}
+	if _, ok := got["user.id"]; ok {
+		t.Error("Extract bypassed the custom parser")
+	}
+}
+
+func TestEnded(t *testing.T) {
+	rec := setup(t)
+	resolved := 0
+
+	s := New(context.Background(), "op")
+	s.AttrFromContext("resolved", func(context.Context) (string, bool) {
+		resolved++
+		return "yes", true
+	})
+	if s.Ended() {
+		t.Error("Ended = true before End")
+	}
+	s.End()
+	if !s.Ended() {
+		t.Error("Ended = false after End")
+	}
+
+	s.End()
+	s.EndStatus(codes.Error, "late")
+	if resolved != 1 {
+		t.Errorf("resolver ran %d times, want once", resolved)
+	}
+	if code := onlySpan(t, rec).Status.Code; code == codes.Error {
+		t.Error("EndStatus after End changed the status")
+	}
+}
+
+func TestNonFiniteFloats(t *testing.T) {
+	setup(t)
+	attrs := NewAttrs().FloatKV("nan", math.NaN()).FloatKV("pos", math.Inf(1)).FloatKV("neg", math.Inf(-1)).FloatKV("ok", 1.5)
+
+	got := attrMap(attrs.Parse())
+	for _, key := range []attribute.Key{"nan", "pos", "neg"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("%s was kept by the default drop policy", key)
+		}
+		if !got[key+".invalid"].AsBool() {
+			t.Errorf("%s.invalid is not set", key)
+		}
+	}
+	if got["ok"].AsFloat64() != 1.5 {
+		t.Error("finite float was dropped")
+	}
+
+	SetNonFiniteFloatPolicy(NonFiniteString)
+	got = attrMap(attrs.Parse())
+	for key, want := range map[attribute.Key]string{"nan": "NaN", "pos": "+Inf", "neg": "-Inf"} {
+		if v := got[key].AsString(); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+}
+
+func TestIncrAttr(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "batch")
+	for range 4 {
+		s.IncrAttr("items.processed", 1)
+	}
+	s.IncrAttr("items.processed", 10)
+	s.End()
+
+	if v := attrMap(onlySpan(t, rec).Attributes)["items.processed"].AsInt64(); v != 14 {
+		t.Errorf("items.processed = %d, want 14", v)
+	}
+}
+
+func TestStrictTracerName(t *testing.T) {
+	rec := setup(t)
+	var warnings []string
+	SetWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+	SetStrictTracerName(true)
+
+	s := New(context.Background(), "anonymous")
+	s.Attrs.StrKV("k", "v")
+	s.Event("ignored").Add()
+	s.End()
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "anonymous") {
+		t.Errorf("warnings = %q, want one naming the span", warnings)
+	}
+	if s.Span.IsRecording() {
+		t.Error("strict mode returned a recording span")
+	}
+	if n := len(rec.Spans()); n != 0 {
+		t.Errorf("got %d exported spans, want 0", n)
+	}
+
+	SetDefaultTracerName("app")
+	New(context.Background(), "named").End()
+	if len(warnings) != 1 || len(rec.Spans()) != 1 {
+		t.Error("strict mode rejected a span using the default tracer name")
+	}
+}
+
+func TestNoopChildKeepsContext(t *testing.T) {
+	setup(t)
+	SetWarnFunc(func(string) {})
+	SetStrictTracerName(true)
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestIDKey{}, "r-1"), time.Hour)
+	defer cancel()
+	parent := New(ctx, "strict")
+	child := parent.Child("child").Child("grandchild")
+	defer child.End()
+
+	if _, ok := child.Ctx.Deadline(); !ok || child.Ctx.Value(requestIDKey{}) != "r-1" {
+		t.Error("child of a strict-mode no-op span lost the caller's deadline or values")
+	}
+	if child.Ctx != ctx {
+		t.Error("child of a no-op span has its own Ctx, want the parent's")
+	}
+}
+
+func TestSpanString(t *testing.T) {
+	setup(t)
+	SetRedactor(RedactKeys("password"))
+
+	s := New(context.Background(), "login")
+	defer s.End()
+	s.Attrs.StrKV("user", "ada").StrKV("password", "hunter2")
+	got := fmt.Sprintf("%v", s)
+
+	for _, want := range []string{"login", s.TraceID(), s.SpanID(), "user=ada", "password=" + redactedValue} {
+		if !strings.Contains(got, want) {
+			t.Errorf("String() = %q, missing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("String() = %q leaks a redacted value", got)
+	}
+}
+
+type requestIDKey struct{}
+
+func TestAttrFromContext(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.AttrFromContext("request.id", func(ctx context.Context) (string, bool) {
+		id, ok := ctx.Value(requestIDKey{}).(string)
+		return id, ok
+	})
+	s.AttrFromContext("absent", func(context.Context) (string, bool) { return "", false })
+	s.Ctx = context.WithValue(s.Ctx, requestIDKey{}, "r-42")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if got["request.id"].AsString() != "r-42" {
+		t.Errorf("request.id = %q, want r-42", got["request.id"].AsString())
+	}
+	if _, ok := got["absent"]; ok {
+		t.Error("resolver returning false added its attribute")
+	}
+}
+
+func TestAddLinkWithTime(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+
+	cause := New(context.Background(), "cause")
+	cause.End()
+	at := clock.Now()
+	clock.Advance(90 * time.Second)
+
+	s := New(context.Background(), "effect")
+	s.AddLinkWithTime(cause.Ctx, at)
+	s.End()
+
+	effect := spanNamed(t, rec, "effect")
+	if v := attrMap(effect.Attributes)["link.age_ms"].AsInt64(); v != 90000 {
+		t.Errorf("link.age_ms = %d, want 90000", v)
+	}
+	if len(effect.Links) != 1 || effect.Links[0].SpanContext.SpanID() != cause.SpanIDRaw() {
+		t.Errorf("links = %v, want one to the cause", effect.Links)
+	}
+}
+
+func TestEventOffset(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.Event("checkpoint").Offset(200 * time.Millisecond).Add()
+	s.End()
+
+	span := onlySpan(t, rec)
+	if got, want := span.Events[0].Time, span.StartTime.Add(200*time.Millisecond); !got.Equal(want) {
+		t.Errorf("event time = %v, want %v", got, want)
+	}
+}
+
+func TestCopyAttrsFrom(t *testing.T) {
+	rec := setup(t)
+
+	src := New(context.Background(), "src")
+	src.Attrs.StrKV("tenant", "acme").IntKV("items", 3)
+	dst := New(context.Background(), "dst")
+	dst.Attrs.StrKV("tenant", "old").BoolKV("rollup", true)
+	dst.CopyAttrsFrom(src)
+	dst.CopyAttrsFrom(nil)
+	(*Span)(nil).CopyAttrsFrom(src)
+	dst.End()
+	src.End()
+
+	got := attrMap(spanNamed(t, rec, "dst").Attributes)
+	if got["tenant"].AsString() != "acme" || got["items"].AsInt64() != 3 || !got["rollup"].AsBool() {
+		t.Errorf("dst attributes = %v", got)
+	}
+}
+
+// capturingSpan records the keys passed to SetAttributes.
+type capturingSpan struct {
+	trace.Span
+	keys []attribute.Key
+}
+
+func (c *capturingSpan) SetAttributes(kvs ...attribute.KeyValue) {
+	for _, kv := range kvs {
+		c.keys = append(c.keys, kv.Key)
+	}
+}
+
+func TestExtractSorted(t *testing.T) {
+	setup(t)
+	SetExtractSorted(true)
+
+	s := New(context.Background(), "op")
+	defer s.End()
+	fake := &capturingSpan{Span: s.Span}
+	s.Span = fake
+	s.Attrs.StrKV("zeta", "z").IntKV("alpha", 1).BoolKV("mid", true).FloatKV("beta", 2)
+	s.Extract()
+	s.Span = fake.Span
+
+	if want := []attribute.Key{"alpha", "beta", "mid", "zeta"}; !slices.Equal(fake.keys, want) {
+		t.Errorf("SetAttributes keys = %v, want %v", fake.keys, want)
+	}
+}
+
+func TestNoop(t *testing.T) {
+	rec := setup(t)
+
+	s := Noop()
+	s.Attrs.StrKV("k", "v")
+	s.Event("e").Str("k", "v").Offset(time.Second).Add()
+	s.Error(errors.New("ignored"), true)
+	s.SError("ignored")
+	s.IncrAttr("n", 1)
+	s.CacheEvent("k", false)
+	s.AddLink(context.Background())
+	s.Extract()
+	s.Child("child").End()
+	s.End()
+	s.End()
+
+	if n := len(rec.Spans()); n != 0 {
+		t.Errorf("got %d spans, want none", n)
+	}
+	if s.Ended() {
+		t.Error("End on a no-op span marked it ended")
+	}
+}
+
+func TestMaxSpanNameLen(t *testing.T) {
+	rec := setup(t)
+	SetMaxSpanNameLen(10)
+
+	New(context.Background(), "GET /users/8f14e45fceea167a").End()
+	s := New(context.Background(), "short")
+	s.SetName("renamed-to-something-long")
+	s.End()
+	New(context.Background(), "exactly-10").End()
+
+	for _, want := range []string{"GET /users...", "renamed-to...", "exactly-10"} {
+		spanNamed(t, rec, want)
+	}
+}
+
+func TestEpochMillisKV(t *testing.T) {
+	got := attrMap(NewAttrs().EpochMillisKV("created_at", 1700000000123).Parse())
+
+	if v := got["created_at"].AsInt64(); v != 1700000000123 {
+		t.Errorf("created_at = %d, want 1700000000123", v)
+	}
+	if v, want := got["created_at.iso"].AsString(), "2023-11-14T22:13:20.123Z"; v != want {
+		t.Errorf("created_at.iso = %q, want %q", v, want)
+	}
+}
+
+func TestReset(t *testing.T) {
+	setup(t)
+
+	s := New(context.Background(), "pooled")
+	s.Attrs.StrKV("k", "v").IntKV("n", 1)
+	s.Event("e").Add()
+	s.Error(errors.New("failed"))
+	s.End()
+	strs := s.Attrs.Str
+	s.Reset()
+
+	if s.Ctx != nil || s.Span != nil {
+		t.Error("Reset left Ctx or Span set")
+	}
+	if s.Attrs.len() != 0 {
+		t.Errorf("Reset left %d attributes", s.Attrs.len())
+	}
+	if s.Ended() || s.eventCount.Load() != 0 || s.errorCount != 0 || s.status != codes.Unset || s.name != "" {
+		t.Error("Reset left internal state set")
+	}
+	s.Attrs.StrKV("reused", "yes")
+	if len(strs) != 1 {
+		t.Error("Reset did not keep the attribute maps for reuse")
+	}
+}
+
+func TestAttrsEqualExcept(t *testing.T) {
+	a := NewAttrs().StrKV("op", "read").StrKV("request.id", "r-1").IntKV("ts", 100)
+	b := NewAttrs().StrKV("op", "read").StrKV("request.id", "r-2").IntKV("ts", 200)
+
+	if !AttrsEqualExcept(a, b, "request.id", "ts") {
+		t.Error("sets differing only in ignored keys compare unequal")
+	}
+	if AttrsEqualExcept(a, b, "request.id") {
+		t.Error("sets differing in a non-ignored key compare equal")
+	}
+	if !AttrsEqualExcept(a, NewAttrs().StrKV("op", "read"), "request.id", "ts") {
+		t.Error("an ignored key missing on one side made the sets unequal")
+	}
+}
+
+func TestEndWithCtx(t *testing.T) {
+	rec := setup(t)
+	errShutdown := errors.New("server shutting down")
+
+	func() {
+		s := New(context.Background(), "normal")
+		defer EndWithCtx(s)()
+	}()
+	func() {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		s := New(ctx, "cancelled")
+		defer EndWithCtx(s)()
+		cancel(errShutdown)
+	}()
+	func() {
+		s := New(context.Background(), "panicking")
+		defer EndWithCtx(s)()
+		panic("boom")
+	}()
+
+	if code := spanNamed(t, rec, "normal").Status.Code; code == codes.Error {
+		t.Error("normal span ended as Error")
+	}
+	if st := spanNamed(t, rec, "cancelled").Status; st.Code != codes.Error || st.Description != errShutdown.Error() {
+		t.Errorf("cancelled status = %v, want Error with the cancel cause", st)
+	}
+	panicking := spanNamed(t, rec, "panicking")
+	if panicking.Status.Code != codes.Error || attrMap(panicking.Attributes)["panic.value"].AsString() != "boom" {
+		t.Errorf("panicking span = %v %v", panicking.Status, panicking.Attributes)
+	}
+}
+
+func TestWorker(t *testing.T) {
+	rec := setup(t)
+
+	New(context.Background(), "option", WithWorker("w-1")).End()
+	s := New(context.Background(), "method")
+	s.SetWorker("w-2")
+	s.End()
+
+	if v := attrMap(spanNamed(t, rec, "option").Attributes)["worker.id"].AsString(); v != "w-1" {
+		t.Errorf("worker.id via option = %q, want w-1", v)
+	}
+	if v := attrMap(spanNamed(t, rec, "method").Attributes)["worker.id"].AsString(); v != "w-2" {
+		t.Errorf("worker.id via SetWorker = %q, want w-2", v)
+	}
+}
+
+func TestUserIDKV(t *testing.T) {
+	setup(t)
+	SetHashSalt("pepper")
+
+	first := attrMap(NewAttrs().UserIDKV("user.id", "u-123").Parse())["user.id"].AsString()
+	again := attrMap(NewAttrs().UserIDKV("user.id", "u-123").Parse())["user.id"].AsString()
+	other := attrMap(NewAttrs().UserIDKV("user.id", "u-124").Parse())["user.id"].AsString()
+
+	if first != again {
+		t.Errorf("same user hashed to %q and %q", first, again)
+	}
+	if first == "u-123" || len(first) != 64 {
+		t.Errorf("user.id = %q, want a hex SHA-256", first)
+	}
+	if first == other {
+		t.Error("different users share a hash")
+	}
+	SetHashSalt("other")
+	if salted := attrMap(NewAttrs().UserIDKV("user.id", "u-123").Parse())["user.id"].AsString(); salted == first {
+		t.Error("changing the salt did not change the hash")
+	}
+}
+
+func TestAttrsClear(t *testing.T) {
+	a := NewAttrs().StrKV("s", "x").BoolKV("b", true).IntKV("i", 1).FloatKV("f", 1.5).
+		SliceKV("sl", []string{"x"}).ValueKV("v", attribute.Int64Value(2))
+
+	if a.Clear() != a {
+		t.Error("Clear did not return its receiver")
+	}
+	if a.len() != 0 || len(a.Parse()) != 0 {
+		t.Errorf("Clear left %d attributes", a.len())
+	}
+	a.StrKV("next", "y")
+	if got := a.Parse(); len(got) != 1 || got[0].Key != "next" {
+		t.Errorf("reused builder parsed %v, want only next", got)
+	}
+}
+
+func TestReuseParentSampling(t *testing.T) {
+	rec := setup(t, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())))
+	SetReuseParentSampling(true)
+
+	parent := New(context.Background(), "parent")
+	a, b := parent.Child("a"), parent.Child("b")
+	grandchild := a.Child("grandchild")
+	for _, s := range []*Span{a, b, grandchild} {
+		if s.Span.IsRecording() || s.Span.SpanContext().IsSampled() {
+			t.Errorf("%s is sampled under an unsampled parent", s.name)
+		}
+		if s.TraceIDRaw() != parent.TraceIDRaw() {
+			t.Errorf("%s trace ID = %s, want the parent's %s", s.name, s.TraceID(), parent.TraceID())
+		}
+		if !s.SpanIDRaw().IsValid() || s.SpanIDRaw() == parent.SpanIDRaw() {
+			t.Errorf("%s span ID = %s, want its own", s.name, s.SpanID())
+		}
+	}
+	if a.SpanIDRaw() == b.SpanIDRaw() || grandchild.SpanIDRaw() == a.SpanIDRaw() {
+		t.Error("children share a span ID")
+	}
+	grandchild.End()
+	b.End()
+	a.End()
+	parent.End()
+
+	if n := len(rec.Spans()); n != 0 {
+		t.Errorf("got %d spans, want none", n)
+	}
+}
+
+func benchmarkChildren(b *testing.B, reuse bool) {
+	setup(b, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())))
+	SetReuseParentSampling(reuse)
+	parent := New(context.Background(), "parent")
+	defer parent.End()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for range 1000 {
+			parent.Child("child").End()
+		}
+	}
+}
+
+func BenchmarkChildren1000(b *testing.B) {
+	b.Run("sampler", func(b *testing.B) { benchmarkChildren(b, false) })
+	b.Run("cached", func(b *testing.B) { benchmarkChildren(b, true) })
+}
+
+func TestDebugWarnsOnAttrAfterEnd(t *testing.T) {
+	setup(t)
+	var warnings []string
+	SetWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+
+	s := New(context.Background(), "op")
+	s.CacheEvent("k", true)
+	s.End()
+	s.Attrs.StrKV("late", "x")
+	if len(warnings) != 0 {
+		t.Fatalf("warned without debug mode: %q", warnings)
+	}
+
+	SetDebug(true)
+	s = New(context.Background(), "op")
+	s.CacheEvent("k", true)
+	s.End()
+	s.End()
+	if len(warnings) != 0 {
+		t.Fatalf("End itself triggered warnings: %q", warnings)
+	}
+	s.Attrs.IntKV("late", 1)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"late"`) {
+		t.Errorf("warnings = %q, want one for late", warnings)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	rec := setup(t)
+	AddValueRedactPattern(regexp.MustCompile(`^sk_\w+`), redactedValue)
+
+	plain := New(context.Background(), "plain")
+	plain.IdempotencyKey("order-77")
+	plain.End()
+	secret := New(context.Background(), "secret")
+	secret.IdempotencyKey("sk_live_abc")
+	secret.End()
+
+	if v := attrMap(spanNamed(t, rec, "plain").Attributes)["idempotency.key"].AsString(); v != "order-77" {
+		t.Errorf("idempotency.key = %q, want order-77", v)
+	}
+	if v := attrMap(spanNamed(t, rec, "secret").Attributes)["idempotency.key"].AsString(); v != redactedValue {
+		t.Errorf("secret idempotency.key = %q, want it redacted", v)
+	}
+}
+
+func TestMaxStatusDescLen(t *testing.T) {
+	rec := setup(t)
+	SetMaxStatusDescLen(12)
+
+	s := New(context.Background(), "op")
+	s.Error(errors.New("connection reset by peer while reading response"))
+	s.End()
+
+	if d := onlySpan(t, rec).Status.Description; d != "connection r..." {
+		t.Errorf("status description = %q, want %q", d, "connection r...")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	setup(t)
+	a := New(context.Background(), "a")
+	b := New(context.Background(), "b")
+	a.End()
+	b.End()
+
+	s := New(context.Background(), "op")
+	s.AddLink(a.Ctx)
+	s.AddLink(b.Ctx, *NewAttrs().StrKV("link.reason", "retry"))
+	s.End()
+
+	links := s.Links()
+	if len(links) != 2 {
+		t.Fatalf("got %d links, want 2", len(links))
+	}
+	if !links[0].SpanContext.Equal(a.Span.SpanContext()) || !links[1].SpanContext.Equal(b.Span.SpanContext()) {
+		t.Errorf("links = %v, want the contexts of a and b in order", links)
+	}
+}
+
+func TestNewTimeout(t *testing.T) {
+	rec := setup(t)
+
+	s, cancel := NewTimeout(context.Background(), "op", time.Minute)
+	deadline, ok := s.Ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Fatalf("span context deadline = %v, %v, want one within a minute", deadline, ok)
+	}
+	cancel()
+	if !errors.Is(s.Ctx.Err(), context.Canceled) {
+		t.Errorf("span context error after cancel = %v, want context.Canceled", s.Ctx.Err())
+	}
+	s.End()
+
+	if v := attrMap(onlySpan(t, rec).Attributes)["timeout_ms"].AsInt64(); v != 60000 {
+		t.Errorf("timeout_ms = %d, want 60000", v)
+	}
+}
+
+func TestRollout(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.Rollout("checkout", true, 25)
+	s.Rollout("search", false, 140)
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if !got["rollout.checkout.active"].AsBool() || got["rollout.checkout.percentage"].AsFloat64() != 25 {
+		t.Errorf("checkout rollout = %v, %v, want true, 25",
+			got["rollout.checkout.active"].Emit(), got["rollout.checkout.percentage"].Emit())
+	}
+	if got["rollout.search.active"].AsBool() || got["rollout.search.percentage"].AsFloat64() != 100 {
+		t.Errorf("search rollout = %v, %v, want false, 100 after clamping",
+			got["rollout.search.active"].Emit(), got["rollout.search.percentage"].Emit())
+	}
+}
+
+func TestMaxLinksPerSpan(t *testing.T) {
+	rec := setup(t)
+	SetMaxLinksPerSpan(3)
+	other := New(context.Background(), "other")
+	other.End()
+
+	s := New(context.Background(), "op")
+	for range 5 {
+		s.AddLink(other.Ctx)
+	}
+	s.End()
+
+	if n := len(s.Links()); n != 3 {
+		t.Errorf("tracked %d links, want 3", n)
+	}
+	span := spanNamed(t, rec, "op")
+	if len(span.Links) != 3 {
+		t.Errorf("recorded %d links, want 3", len(span.Links))
+	}
+	if v := attrMap(span.Attributes)["dropped_links"].AsInt64(); v != 2 {
+		t.Errorf("dropped_links = %d, want 2", v)
+	}
+}
+
+func stringAttrs() *spanAttributes {
+	a := NewAttrs()
+	for i := range 8 {
+		a.StrKV(fmt.Sprintf("key.%d", i), "value")
+	}
+	return a
+}
+
+func TestParseStrings(t *testing.T) {
+	a := stringAttrs()
+	got, want := attrMap(a.ParseStrings()), attrMap(a.Parse())
+	if len(got) != 8 || len(got) != len(want) {
+		t.Fatalf("ParseStrings gave %d attributes, Parse %d, want 8", len(got), len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+}
+
+func BenchmarkParseStrings(b *testing.B) {
+	a := stringAttrs()
+	b.Run("Parse", func(b *testing.B) {
+		for b.Loop() {
+			a.Parse()
+		}
+	})
+	b.Run("ParseStrings", func(b *testing.B) {
+		for b.Loop() {
+			a.ParseStrings()
+		}
+	})
+}
+
+func TestAutoOK(t *testing.T) {
+	rec := setup(t)
+
+	New(context.Background(), "unset").End()
+	SetAutoOK(true)
+	New(context.Background(), "ok").End()
+	failed := New(context.Background(), "failed")
+	failed.Error(errors.New("boom"))
+	failed.End()
+
+	for name, want := range map[string]codes.Code{"unset": codes.Unset, "ok": codes.Ok, "failed": codes.Error} {
+		if got := spanNamed(t, rec, name).Status.Code; got != want {
+			t.Errorf("span %q status = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSecondaryKind(t *testing.T) {
+	rec := setup(t)
+	var warnings []string
+	SetWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+
+	s := New(context.Background(), "publish", WithKind("client"))
+	s.SecondaryKind("producer")
+	s.SecondaryKind("sideways")
+	s.End()
+
+	span := onlySpan(t, rec)
+	if span.SpanKind != trace.SpanKindClient {
+		t.Errorf("kind = %v, want client", span.SpanKind)
+	}
+	if v := attrMap(span.Attributes)["span.secondary_kind"].AsString(); v != "producer" {
+		t.Errorf("span.secondary_kind = %q, want producer", v)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "sideways") {
+		t.Errorf("warnings = %q, want one for the unknown kind", warnings)
+	}
+}
+
+func TestEndUsesClock(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+
+	s := New(context.Background(), "op")
+	clock.Advance(3 * time.Second)
+	s.End()
+
+	span := onlySpan(t, rec)
+	if !span.EndTime.Equal(clock.Now()) {
+		t.Errorf("end time = %v, want the clock's %v", span.EndTime, clock.Now())
+	}
+	if d := span.EndTime.Sub(span.StartTime); d != 3*time.Second {
+		t.Errorf("duration = %v, want 3s", d)
+	}
+}
+
+func TestWithComponent(t *testing.T) {
+	rec := setup(t)
+
+	New(context.Background(), "none").End()
+	New(context.Background(), "option", WithComponent("billing")).End()
+	SetDefaultComponent("core")
+	New(context.Background(), "default").End()
+	New(context.Background(), "override", WithComponent("billing")).End()
+
+	for name, want := range map[string]string{"none": "", "option": "billing", "default": "core", "override": "billing"} {
+		if v := attrMap(spanNamed(t, rec, name).Attributes)["component"].AsString(); v != want {
+			t.Errorf("span %q component = %q, want %q", name, v, want)
+		}
+	}
+}
+
+func TestEndStatus(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "op")
+	s.Attrs.StrKV("tenant", "acme")
+	s.EndStatus(codes.Error, "upstream unavailable")
+
+	span := onlySpan(t, rec)
+	if span.Status.Code != codes.Error || span.Status.Description != "upstream unavailable" {
+		t.Errorf("status = %v %q, want Error upstream unavailable", span.Status.Code, span.Status.Description)
+	}
+	if v := attrMap(span.Attributes)["tenant"].AsString(); v != "acme" {
+		t.Errorf("tenant = %q, want attributes extracted", v)
+	}
+	if !s.Ended() {
+		t.Error("EndStatus did not end the span")
+	}
+}
+
+func TestCountBy(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "order")
+	s.CountBy("item_type", map[string]int{"book": 3, "dvd": 1})
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)
+	if got["count.item_type.book"].AsInt64() != 3 || got["count.item_type.dvd"].AsInt64() != 1 {
+		t.Errorf("attributes = %v, want count.item_type.book=3 and count.item_type.dvd=1", got)
+	}
+}
+
+func TestRecordRemainingBudget(t *testing.T) {
+	rec := setup(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	parent := New(ctx, "parent", WithRecordRemainingBudget())
+	time.Sleep(20 * time.Millisecond)
+	child := parent.Child("child", WithRecordRemainingBudget())
+	child.End()
+	parent.End()
+	New(context.Background(), "unbounded", WithRecordRemainingBudget()).End()
+
+	p := attrMap(spanNamed(t, rec, "parent").Attributes)["remaining_budget_ms"].AsInt64()
+	c := attrMap(spanNamed(t, rec, "child").Attributes)["remaining_budget_ms"].AsInt64()
+	if c <= 0 || c >= p || p > 1000 {
+		t.Errorf("remaining_budget_ms parent = %d, child = %d, want 0 < child < parent <= 1000", p, c)
+	}
+	if _, ok := attrMap(spanNamed(t, rec, "unbounded").Attributes)["remaining_budget_ms"]; ok {
+		t.Error("remaining_budget_ms recorded without a deadline")
+	}
+}
+
+func TestAnySliceKV(t *testing.T) {
+	setup(t)
+	var warnings []string
+	SetWarnFunc(func(msg string) { warnings = append(warnings, msg) })
+
+	got := attrMap(NewAttrs().
+		AnySliceKV("mixed", []any{1, "x", true}).
+		AnySliceKV("bad", []any{math.Inf(1)}).
+		Parse())
+	if v := got["mixed"].AsString(); v != `[1,"x",true]` {
+		t.Errorf("mixed = %q, want [1,\"x\",true]", v)
+	}
+	if _, ok := got["bad"]; ok || len(warnings) != 1 {
+		t.Errorf("unencodable slice recorded as %v with warnings %q, want it dropped with a warning", got["bad"].Emit(), warnings)
+	}
+}
+
+func TestQueueDepth(t *testing.T) {
+	rec := setup(t)
+	depth := 0
+	lazy := WithQueueDepth(func() int { depth++; return 7 })
+
+	s := New(context.Background(), "method")
+	s.QueueDepth(12)
+	s.End()
+	New(context.Background(), "option", lazy).End()
+	if depth != 1 {
+		t.Errorf("queue depth func ran %d times, want once at start", depth)
+	}
+
+	if v := attrMap(spanNamed(t, rec, "method").Attributes)["queue.depth"].AsInt64(); v != 12 {
+		t.Errorf("method queue.depth = %d, want 12", v)
+	}
+	if v := attrMap(spanNamed(t, rec, "option").Attributes)["queue.depth"].AsInt64(); v != 7 {
+		t.Errorf("option queue.depth = %d, want 7", v)
+	}
+}
+
+func TestVariant(t *testing.T) {
+	rec := setup(t)
+
+	s := New(context.Background(), "rank")
+	s.Variant("v1")
+	s.Variant("v2-fast")
+	s.End()
+
+	got := attrMap(onlySpan(t, rec).Attributes)["code.variant"].AsStringSlice()
+	if !slices.Equal(got, []string{"v1", "v2-fast"}) {
+		t.Errorf("code.variant = %q, want [v1 v2-fast]", got)
+	}
+}
+
+func TestSliceAppend(t *testing.T) {
+	tags := make([]string, 1, 4)
+	tags[0] = "a"
+	attrs := NewAttrs().SliceKV("tags", tags)
+	attrs.SliceAppend("tags", "b").SliceAppend("tags", "c", "d")
+	attrs.SliceAppend("new", "x")
+
+	got := attrMap(attrs.Parse())
+	if v := got["tags"].AsStringSlice(); !slices.Equal(v, []string{"a", "b", "c", "d"}) {
+		t.Errorf("tags = %q, want [a b c d]", v)
+	}
+	if v := got["new"].AsStringSlice(); !slices.Equal(v, []string{"x"}) {
+		t.Errorf("new = %q, want [x]", v)
+	}
+	if tags[:2][1] != "" {
+		t.Error("SliceAppend wrote into the caller's backing array")
+	}
+}
+
+func TestSLO(t *testing.T) {
+	rec := setup(t)
+	clock := newFakeClock()
+	SetClock(clock.Now)
+
+	slow := New(context.Background(), "slow")
+	slow.SLO(200 * time.Millisecond)
+	fast := New(context.Background(), "fast")
+	fast.SLO(time.Second)
+	clock.Advance(350 * time.Millisecond)
+	slow.End()
+	fast.End()
+
+	got := attrMap(spanNamed(t, rec, "slow").Attributes)
+	if got["slo.target_ms"].AsInt64() != 200 || !got["slo.breached"].AsBool() || got["slo.over_by_ms"].AsInt64() != 150 {
+		t.Errorf("slow span SLO attributes = %v, want target 200, breached, over by 150", got)
+	}
+	got = attrMap(spanNamed(t, rec, "fast").Attributes)
+	if _, over := got["slo.over_by_ms"]; got["slo.breached"].AsBool() || over {
+		t.Errorf("fast span SLO attributes = %v, want not breached", got)
+	}
+}
+
+func TestEndWithCtxCause(t *testing.T) {
+	rec := setup(t)
+	errQuota := errors.New("tenant over quota")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s := New(ctx, "op")
+	func() {
+		defer EndWithCtx(s)()
+		cancel(errQuota)
+	}()
+
+	if d := onlySpan(t, rec).Status.Description; d != errQuota.Error() {
+		t.Errorf("status description = %q, want the cancel cause %q", d, errQuota)
+	}
+}
+
+func TestWithRetryOf(t *testing.T) {
+	rec := setup(t)
+
+	parent := New(context.Background(), "request")
+	first := parent.Child("attempt")
+	first.End()
+	prev := first.SpanID()
+	retry := parent.Child("attempt", WithRetryOf(prev))
+	retry.End()
+	parent.End()
+
+	var span SpanStub
+	for _, sp := range rec.Spans() {
+		if sp.SpanContext.SpanID() == retry.Span.SpanContext().SpanID() {
+			span = sp
+		}
+	}
+	if v := attrMap(span.Attributes)["retry.of_span_id"].AsString(); v != prev {
+		t.Errorf("retry.of_span_id = %q, want %q", v, prev)
+	}
+	if len(span.Links) != 1 || span.Links[0].SpanContext.SpanID().String() != prev ||
+		span.Links[0].SpanContext.TraceID() != parent.TraceIDRaw() {
+		t.Errorf("links = %v, want one to the previous attempt", span.Links)
+	}
+}
@@ -0,0 +1,256 @@
+package tracer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WrapDriver registers drv with database/sql under name, wrapped so each
+// query and exec is recorded as a client span named after the SQL verb,
+// with db.system set as given by WithDBSystem and the statement, literals
+// replaced by ?, as db.statement. Spans cover the call only, not reading
+// the rows. They are created once the driver returns, so a call the
+// driver skips with driver.ErrSkip leaves no span, and the driver itself
+// does not see the span in its context. Like sql.Register, it panics if
+// name is already registered.
+func WrapDriver(name string, drv driver.Driver, opts ...DriverOption) {
+	d := &tracedDriver{Driver: drv, system: "other_sql"}
+	for _, opt := range opts {
+		opt(d)
+	}
+	sql.Register(name, d)
+}
+
+// DriverOption configures WrapDriver.
+type DriverOption func(*tracedDriver)
+
+// WithDBSystem sets the db.system recorded on query spans, e.g.
+// "postgresql". It defaults to "other_sql".
+func WithDBSystem(system string) DriverOption {
+	return func(d *tracedDriver) { d.system = system }
+}
+
+// BeginTx starts a span covering a database transaction, marked with
+// db.transaction=true, and returns it with the context to run the
+// transaction's queries in, so their spans become its children.
+func BeginTx(ctx context.Context, spanName string) (*Span, context.Context) {
+	s := New(ctx, spanName, WithKind("client"))
+	s.Attrs.BoolKV("db.transaction", true)
+	return s, s.Ctx
+}
+
+type tracedDriver struct {
+	driver.Driver
+	system string
+}
+
+func (d *tracedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, system: d.system}, nil
+}
+
+// sqlLiteral matches string and numeric literals, and placeholders such
+// as $1 and :name so they are matched whole and kept.
+var sqlLiteral = regexp.MustCompile(`\$\d+|:\w+|'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// sanitizeSQL replaces string and numeric literals with ? so statements
+// do not leak values and group well.
+func sanitizeSQL(query string) string {
+	return sqlLiteral.ReplaceAllStringFunc(query, func(m string) string {
+		if m[0] == '$' || m[0] == ':' {
+			return m
+		}
+		return "?"
+	})
+}
+
+// recordQuery records a query or exec that ran from began until now as a
+// client span from ctx, with err on it. There is none for driver.ErrSkip,
+// which only tells database/sql to take another path.
+func recordQuery(ctx context.Context, system, query string, began time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+
+	name := "sql"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		name = strings.ToUpper(fields[0])
+	}
+
+	s := start(ctx, name, startOptions{Kind: "client", startTime: began})
+	s.Attrs.StrKV("db.system", system).StrKV("db.statement", sanitizeSQL(query))
+	s.Error(err, true)
+	s.End()
+}
+
+type tracedConn struct {
+	driver.Conn
+	system string
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	began := now()
+	rows, err := q.QueryContext(ctx, query, args)
+	recordQuery(ctx, c.system, query, began, err)
+	return rows, err
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	began := now()
+	res, err := e.ExecContext(ctx, query, args)
+	recordQuery(ctx, c.system, query, began, err)
+	return res, err
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, system: c.system, query: query}, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	// Without ConnBeginTx, apply the checks database/sql makes for such
+	// drivers, which it skips because the wrapper implements BeginTx.
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errIsolationLevel
+	}
+	if opts.ReadOnly {
+		return nil, errReadOnly
+	}
+	tx, err := c.Conn.Begin()
+	if err == nil && ctx.Err() != nil {
+		_ = tx.Rollback()
+		return nil, ctx.Err()
+	}
+	return tx, err
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	system string
+	query  string
+}
+
+func (st *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	began := now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := st.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			rows, err = st.Stmt.Query(values)
+		}
+	}
+	recordQuery(ctx, st.system, st.query, began, err)
+	return rows, err
+}
+
+func (st *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	began := now()
+	var (
+		res driver.Result
+		err error
+	)
+	if e, ok := st.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			res, err = st.Stmt.Exec(values)
+		}
+	}
+	recordQuery(ctx, st.system, st.query, began, err)
+	return res, err
+}
+
+func (st *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := st.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
+
+var (
+	errNamedArgs      = errors.New("driver does not support named arguments")
+	errIsolationLevel = errors.New("sql: driver does not support non-default isolation level")
+	errReadOnly       = errors.New("sql: driver does not support read-only transactions")
+)
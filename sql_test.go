@@ -0,0 +1,148 @@
+package tracer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+)
+
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+// stubConn answers queries directly but skips ExecContext, so execs go
+// through Prepare.
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return stubStmt{}, nil }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return stubTx{}, nil }
+
+func (stubConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return stubRows{}, nil
+}
+
+func (stubConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+type stubStmt struct{}
+
+func (stubStmt) Close() error                               { return nil }
+func (stubStmt) NumInput() int                              { return -1 }
+func (stubStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (stubStmt) Query([]driver.Value) (driver.Rows, error)  { return stubRows{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string         { return nil }
+func (stubRows) Close() error              { return nil }
+func (stubRows) Next([]driver.Value) error { return io.EOF }
+
+var registerStub sync.Once
+
+// openStub opens a database on stubDriver wrapped by WrapDriver, which is
+// registered once per test binary as database/sql cannot unregister it.
+func openStub(t *testing.T) *sql.DB {
+	t.Helper()
+	registerStub.Do(func() {
+		WrapDriver("tracer-stub", stubDriver{}, WithDBSystem("stub"))
+	})
+	db, err := sql.Open("tracer-stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWrapDriver(t *testing.T) {
+	rec := setup(t)
+	db := openStub(t)
+
+	ctx := context.Background()
+	for _, q := range []string{
+		"select * from users where name = 'ada' and age > 36",
+		"select * from users where id = $1",
+	} {
+		rows, err := db.QueryContext(ctx, q, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	if _, err := db.ExecContext(ctx, "update users set name = :name", "ada"); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Spans()
+	want := []string{
+		"select * from users where name = ? and age > ?",
+		"select * from users where id = $1",
+		"update users set name = :name",
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans, want one per call and none for the skipped exec", len(spans))
+	}
+	for i, s := range spans {
+		got := attrMap(s.Attributes)
+		if st := got["db.statement"].AsString(); st != want[i] {
+			t.Errorf("span %d db.statement = %q, want %q", i, st, want[i])
+		}
+		if got["db.system"].AsString() != "stub" {
+			t.Errorf("span %d db.system = %q, want stub", i, got["db.system"].AsString())
+		}
+	}
+	if spans[0].Name != "SELECT" || spans[2].Name != "UPDATE" {
+		t.Errorf("span names = %q, %q, want SELECT, UPDATE", spans[0].Name, spans[2].Name)
+	}
+}
+
+func TestWrapDriverTxOptions(t *testing.T) {
+	setup(t)
+	db := openStub(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("default transaction: %v", err)
+	}
+	tx.Rollback()
+
+	for _, opts := range []*sql.TxOptions{{ReadOnly: true}, {Isolation: sql.LevelSerializable}} {
+		if _, err := db.BeginTx(ctx, opts); err == nil {
+			t.Errorf("BeginTx(%+v) on a driver without ConnBeginTx succeeded, want an error", *opts)
+		}
+	}
+	if _, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err == nil ||
+		err.Error() != "sql: driver does not support read-only transactions" {
+		t.Errorf("read-only error = %v, want database/sql's", err)
+	}
+}
+
+func TestBeginTx(t *testing.T) {
+	rec := setup(t)
+
+	tx, ctx := BeginTx(context.Background(), "transfer")
+	New(ctx, "debit").End()
+	New(ctx, "credit").End()
+	tx.End()
+
+	txSpan := spanNamed(t, rec, "transfer")
+	if !attrMap(txSpan.Attributes)["db.transaction"].AsBool() {
+		t.Error("db.transaction not set on the transaction span")
+	}
+	for _, name := range []string{"debit", "credit"} {
+		if p := spanNamed(t, rec, name).Parent.SpanID(); p != txSpan.SpanContext.SpanID() {
+			t.Errorf("%s parent = %s, want the transaction span", name, p)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// streamRingSize bounds how many message events a StreamSpan keeps.
+const streamRingSize = 64
+
+// StreamSpan traces a long-lived stream, such as a WebSocket connection,
+// in a single span. It keeps events for the last streamRingSize messages
+// only, so a busy connection does not grow the span without bound, while
+// counting every message and byte per direction. FlushEvery adds
+// periodic totals while the stream lives. Message and Flush are safe for
+// concurrent use.
+type StreamSpan struct {
+	*Span
+
+	mu      sync.Mutex
+	ring    []streamMessage
+	next    int
+	total   int
+	perDir  map[string]*streamTotals
+	dirKeys []string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	flushers sync.WaitGroup
+}
+
+type streamMessage struct {
+	dir  string
+	size int
+	at   time.Time
+}
+
+type streamTotals struct {
+	messages int
+	bytes    int
+}
+
+// NewStream starts a StreamSpan. See New for opts.
+func NewStream(ctx context.Context, spanName string, opts ...Option) *StreamSpan {
+	return &StreamSpan{
+		Span:   New(ctx, spanName, opts...),
+		perDir: map[string]*streamTotals{},
+		stop:   make(chan struct{}),
+	}
+}
+
+// Message records a message of size bytes flowing in direction dir,
+// e.g. "sent" or "received".
+func (s *StreamSpan) Message(dir string, size int) {
+	m := streamMessage{dir: dir, size: size, at: now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ring) < streamRingSize {
+		s.ring = append(s.ring, m)
+	} else {
+		s.ring[s.next] = m
+	}
+	s.next = (s.next + 1) % streamRingSize
+	s.total++
+
+	t, ok := s.perDir[dir]
+	if !ok {
+		t = &streamTotals{}
+		s.perDir[dir] = t
+		s.dirKeys = append(s.dirKeys, dir)
+	}
+	t.messages++
+	t.bytes += size
+}
+
+// Flush adds a stream.flush event carrying the totals so far as
+// stream.messages and, per direction, stream.<dir>.messages and
+// stream.<dir>.bytes, giving long-lived streams a progress timeline. The
+// kept message events are only added on End.
+func (s *StreamSpan) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.Event("stream.flush").Int("stream.messages", s.total)
+	for _, dir := range s.dirKeys {
+		t := s.perDir[dir]
+		e.Int("stream."+dir+".messages", t.messages).Int("stream."+dir+".bytes", t.bytes)
+	}
+	e.Add()
+}
+
+// FlushEvery calls Flush every interval from a goroutine that End stops
+// and waits for before ending the span. Each flush adds one event, so the span's event count limit caps
+// their number on very long streams. It does nothing for interval <= 0.
+func (s *StreamSpan) FlushEvery(interval time.Duration) {
+	if interval <= 0 || s.noop || s.Ended() {
+		return
+	}
+
+	s.flushers.Add(1)
+	go func() {
+		defer s.flushers.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.Flush()
+			}
+		}
+	}()
+}
+
+// End stops any FlushEvery goroutine and waits for it to exit, then adds
+// the kept message events, oldest first, and the totals as
+// stream.messages, stream.messages_dropped and, per direction,
+// stream.<dir>.messages and stream.<dir>.bytes, then ends the span like
+// Span.End.
+func (s *StreamSpan) End() {
+	if s.noop {
+		return
+	}
+	var r any
+	if !s.noRecover {
+		r = recover()
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.flushers.Wait()
+	if !s.Ended() {
+		s.summarize()
+	}
+	s.end(r)
+}
+
+func (s *StreamSpan) summarize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := 0
+	if len(s.ring) == streamRingSize {
+		start = s.next
+	}
+	for i := range s.ring {
+		m := s.ring[(start+i)%len(s.ring)]
+		s.Event("stream.message").Timestamp(m.at).Str("direction", m.dir).Int("size", m.size).Add()
+	}
+
+	s.Attrs.IntKV("stream.messages", s.total).IntKV("stream.messages_dropped", s.total-len(s.ring))
+	for _, dir := range s.dirKeys {
+		t := s.perDir[dir]
+		s.Attrs.IntKV("stream."+dir+".messages", t.messages).IntKV("stream."+dir+".bytes", t.bytes)
+	}
+}
@@ -0,0 +1,75 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestStreamSpan(t *testing.T) {
+	rec := setup(t)
+
+	s := NewStream(context.Background(), "ws")
+	for i := range streamRingSize + 36 {
+		s.Message("received", 10)
+		if i%2 == 0 {
+			s.Message("sent", 100)
+		}
+	}
+	s.Flush()
+	s.End()
+	s.End()
+
+	span := onlySpan(t, rec)
+	got := attrMap(span.Attributes)
+	total := int64(streamRingSize + 36 + (streamRingSize+36)/2)
+	for key, want := range map[string]int64{
+		"stream.messages":          total,
+		"stream.messages_dropped":  total - streamRingSize,
+		"stream.received.messages": streamRingSize + 36,
+		"stream.received.bytes":    10 * (streamRingSize + 36),
+		"stream.sent.messages":     (streamRingSize + 36) / 2,
+		"stream.sent.bytes":        100 * (streamRingSize + 36) / 2,
+	} {
+		if v := got[attribute.Key(key)].AsInt64(); v != want {
+			t.Errorf("%s = %d, want %d", key, v, want)
+		}
+	}
+
+	messages, flushes := 0, 0
+	for _, e := range span.Events {
+		switch e.Name {
+		case "stream.message":
+			messages++
+		case "stream.flush":
+			flushes++
+		}
+	}
+	if messages != streamRingSize || flushes != 1 {
+		t.Errorf("got %d message and %d flush events, want %d and 1", messages, flushes, streamRingSize)
+	}
+}
+
+func TestStreamFlushEvery(t *testing.T) {
+	rec := setup(t)
+
+	s := NewStream(context.Background(), "ws")
+	s.FlushEvery(time.Millisecond)
+	s.Message("sent", 1)
+	for deadline := time.Now().Add(time.Second); s.eventCount.Load() < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	s.End()
+
+	flushes := 0
+	for _, e := range onlySpan(t, rec).Events {
+		if e.Name == "stream.flush" {
+			flushes++
+		}
+	}
+	if flushes < 2 {
+		t.Errorf("got %d periodic flushes, want at least 2", flushes)
+	}
+}
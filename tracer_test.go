@@ -0,0 +1,97 @@
+package tracer
+
+import (
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// setup installs a TestRecorder built with opts as the global provider,
+// with the W3C propagators, and restores the provider, propagator and
+// package config when the test ends.
+func setup(t testing.TB, opts ...sdktrace.TracerProviderOption) *TestRecorder {
+	t.Helper()
+
+	saved := cfg
+	saved.eventRates = maps.Clone(cfg.eventRates)
+	saved.retryableCategories = maps.Clone(cfg.retryableCategories)
+	saved.promotedKeys = maps.Clone(cfg.promotedKeys)
+	prevProvider := otel.GetTracerProvider()
+	prevPropagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		cfg = saved
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return NewTestRecorder(opts...)
+}
+
+// onlySpan returns the single span rec ended, failing the test otherwise.
+func onlySpan(t testing.TB, rec *TestRecorder) SpanStub {
+	t.Helper()
+
+	spans := rec.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+// attrMap indexes kvs by key.
+func attrMap(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+// spanNamed returns the span rec ended with the given name, failing the
+// test unless there is exactly one.
+func spanNamed(t testing.TB, rec *TestRecorder, name string) SpanStub {
+	t.Helper()
+
+	var found []SpanStub
+	for _, s := range rec.Spans() {
+		if s.Name == name {
+			found = append(found, s)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("got %d spans named %q, want 1", len(found), name)
+	}
+	return found[0]
+}
+
+// fakeClock is a clock for SetClock that only moves when advanced.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
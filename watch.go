@@ -0,0 +1,29 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+)
+
+// WatchDeadline adds a deadline.exceeded event, carrying the timeout the
+// span started with as deadline.timeout_ms, if s.Ctx reaches its deadline
+// before End. The watching goroutine exits on End. It does nothing when
+// s.Ctx has no deadline.
+func (s *Span) WatchDeadline() {
+	ctx := s.Ctx
+	deadline, ok := ctx.Deadline()
+	if !ok || s.done == nil {
+		return
+	}
+	timeout := deadline.Sub(s.start)
+
+	go func() {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				s.Event("deadline.exceeded").Int("deadline.timeout_ms", durationMs(timeout)).Add()
+			}
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchDeadline(t *testing.T) {
+	rec := setup(t)
+
+	s, cancel := NewTimeout(context.Background(), "slow", 10*time.Millisecond)
+	defer cancel()
+	s.WatchDeadline()
+	<-s.Ctx.Done()
+	for deadline := time.Now().Add(time.Second); s.eventCount.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	s.End()
+
+	events := onlySpan(t, rec).Events
+	if len(events) != 1 || events[0].Name != "deadline.exceeded" {
+		t.Fatalf("events = %v, want deadline.exceeded", events)
+	}
+	// The timeout is measured from span start, just after the deadline was set.
+	if v := attrMap(events[0].Attributes)["deadline.timeout_ms"].AsInt64(); v < 5 || v > 10 {
+		t.Errorf("deadline.timeout_ms = %d, want about 10", v)
+	}
+}
+
+func TestWatchDeadlineEndedFirst(t *testing.T) {
+	rec := setup(t)
+
+	s, cancel := NewTimeout(context.Background(), "fast", time.Hour)
+	defer cancel()
+	s.WatchDeadline()
+	s.End()
+
+	if events := onlySpan(t, rec).Events; len(events) != 0 {
+		t.Errorf("events = %v, want none", events)
+	}
+}